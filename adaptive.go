@@ -0,0 +1,151 @@
+package deepjoy
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// adaptiveSizer holds the state used to resize an adaptive pool. The pool
+// grows as soon as borrowers wait longer than the target on average. It
+// shrinks only after no borrower has waited for several consecutive
+// intervals, and then only by closing connections which have sat idle for
+// the minimum idle time, so that a bursty workload does not keep re-dialing
+// the connections it closed between bursts.
+type adaptiveSizer struct {
+	min              int
+	max              int
+	targetWait       time.Duration
+	interval         time.Duration
+	idleTime         time.Duration
+	lastAdapt        time.Time
+	quietIntervals   int
+	lastWaits        uint64
+	lastTimeouts     uint64
+	lastWaitDuration int64
+}
+
+const (
+	// defaultAdaptiveInterval is the interval at which an adaptive pool is
+	// resized.
+	defaultAdaptiveInterval = time.Second
+
+	// defaultAdaptiveIdleTime is the time a connection must sit idle before
+	// an adaptive pool shrinks to close it.
+	defaultAdaptiveIdleTime = time.Second * 30
+
+	// adaptiveShrinkIntervals is the number of consecutive intervals in
+	// which no borrower waits before an adaptive pool shrinks.
+	adaptiveShrinkIntervals = 3
+)
+
+func newAdaptiveSizer(config *clientConfig, now time.Time) *adaptiveSizer {
+	min := config.adaptiveMin
+	if min < 1 {
+		min = 1
+	}
+
+	interval := config.adaptiveInterval
+	if interval <= 0 {
+		interval = defaultAdaptiveInterval
+	}
+
+	idleTime := config.adaptiveIdleTime
+	if idleTime <= 0 {
+		idleTime = defaultAdaptiveIdleTime
+	}
+
+	return &adaptiveSizer{
+		min:        min,
+		max:        config.adaptiveMax,
+		targetWait: config.adaptiveTargetWait,
+		interval:   interval,
+		idleTime:   idleTime,
+		lastAdapt:  now,
+	}
+}
+
+func (s *adaptiveSizer) clamp(capacity int) int {
+	if capacity < s.min {
+		return s.min
+	}
+
+	if capacity > s.max {
+		return s.max
+	}
+
+	return capacity
+}
+
+// Resize the pool based on the borrow waits since the previous interval.
+// The maintenance loop may run more often than the adaptive interval, in
+// which case this method does nothing until the interval has elapsed.
+func (p *pool) adapt() {
+	s := p.adaptive
+	if p.clock.Since(s.lastAdapt) < s.interval {
+		return
+	}
+
+	s.lastAdapt = p.clock.Now()
+
+	var (
+		waits        = atomic.LoadUint64(&p.counters.borrowWaits)
+		timeouts     = atomic.LoadUint64(&p.counters.borrowTimeouts)
+		waitDuration = atomic.LoadInt64(&p.counters.waitDuration)
+		deltaWaits   = waits - s.lastWaits
+		deltaTimeout = timeouts - s.lastTimeouts
+		deltaWait    = waitDuration - s.lastWaitDuration
+	)
+
+	s.lastWaits = waits
+	s.lastTimeouts = timeouts
+	s.lastWaitDuration = waitDuration
+
+	p.mutex.Lock()
+	capacity := p.capacity
+	stale := 0
+	for _, entry := range p.idle {
+		if p.clock.Since(entry.released) >= s.idleTime {
+			stale++
+		}
+	}
+	p.mutex.Unlock()
+
+	if deltaWaits > 0 || deltaTimeout > 0 {
+		s.quietIntervals = 0
+	} else {
+		s.quietIntervals++
+	}
+
+	if deltaTimeout > 0 || (deltaWaits > 0 && time.Duration(deltaWait/int64(deltaWaits)) > s.targetWait) {
+		// Grow by a quarter of the current capacity (at least one)
+		step := capacity / 4
+		if step < 1 {
+			step = 1
+		}
+
+		if target := s.clamp(capacity + step); target != capacity {
+			p.logger.Info("Growing pool after borrowers waited for connections", "capacity", target)
+			p.Resize(target)
+		}
+
+		return
+	}
+
+	if s.quietIntervals >= adaptiveShrinkIntervals && stale > 0 {
+		// Shrink by at most a quarter of the current capacity (at least one)
+		step := capacity / 4
+		if step < 1 {
+			step = 1
+		}
+
+		if stale < step {
+			step = stale
+		}
+
+		if target := s.clamp(capacity - step); target != capacity {
+			p.logger.Info("Shrinking pool after connections sat idle", "capacity", target)
+			p.Resize(target)
+			s.quietIntervals = 0
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package deepjoy
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"time"
 
@@ -21,37 +23,162 @@ type (
 		borrowTimeout     *time.Duration
 		backoff           backoff.Backoff
 		clock             glock.Clock
-		logger            Logger
+		logger            LeveledLogger
+		hooks             clientHooks
+		metrics           *clientMetrics
+		tracing           tracing
+		commandLog        *commandLogger
+		events            eventEmitter
+		dials             *dialHealth
+		restore           func(conn Conn) error
+		resetUnsupported  int32
+		role              string
+		addr              string
+		database          int
+		waitReplicas      int
+		waitTimeout       time.Duration
 	}
 
 	clientConfig struct {
-		dialerFactory  DialerFactory
-		readAddrs      []string
-		password       string
-		database       int
-		connectTimeout time.Duration
-		readTimeout    time.Duration
-		writeTimeout   time.Duration
-		poolCapacity   int
-		backoff        backoff.Backoff
-		breakerFunc    BreakerFunc
-		clock          glock.Clock
-		borrowTimeout  *time.Duration
-		logger         Logger
-	}
-
-	retryableFunc func() (interface{}, error)
+		dialerFactory   DialerFactory
+		replicaFactory  DialerFactory
+		readAddrs       []string
+		netDialer       NetDialFunc
+		onConnect       []OnConnectFunc
+		middleware      []ConnMiddleware
+		hooks           []ClientHook
+		metrics         Metrics
+		metricsInterval time.Duration
+		tracer          Tracer
+		traceArgs       bool
+		redactions      map[string]ArgRedactor
+		password        string
+		database        int
+		connectTimeout  time.Duration
+		readTimeout     time.Duration
+		writeTimeout    time.Duration
+		poolCapacity    int
+		backoff         backoff.Backoff
+		breakerFunc     BreakerFunc
+		clock           glock.Clock
+		borrowTimeout   *time.Duration
+		logger          LeveledLogger
+		waitReplicas    int
+		waitTimeout     time.Duration
+
+		logSampleFirst      int
+		logSampleThereafter int
+
+		slowLogThreshold time.Duration
+		slowLog          CommandLog
+		auditFilter      CommandFilter
+		auditLog         CommandLog
+
+		eventBufferSize int
+		events          *eventBus
+
+		maxIdleTime        time.Duration
+		maxLifetime        time.Duration
+		maxIdleConns       int
+		borrowCheckIdle    time.Duration
+		minIdleConns       int
+		warmupTimeout      time.Duration
+		maxWaiters         int
+		leakThreshold      time.Duration
+		maxConcurrentDials int
+
+		adaptiveMin        int
+		adaptiveMax        int
+		adaptiveTargetWait time.Duration
+		adaptiveInterval   time.Duration
+		adaptiveIdleTime   time.Duration
+
+		useTLS        bool
+		tlsConfig     *tls.Config
+		tlsCAFile     string
+		tlsCertFile   string
+		tlsKeyFile    string
+		tlsServerName string
+		tlsSkipVerify bool
+
+		credentialsProvider        CredentialsProvider
+		replicaCredentialsProvider CredentialsProvider
+		sessionRestorer            func(conn Conn) error
+		replicaSessionRestorer     func(conn Conn) error
+
+		replicaCheckInterval  time.Duration
+		replicaEjectThreshold int
+		replicaFallbackPolicy ReplicaFallbackPolicy
+		replicaEventHandler   ReplicaEventHandler
+		replicaMaxLag         time.Duration
+	}
+
+	retryableFunc func(ctx context.Context) (interface{}, error)
 )
 
 var (
 	// ErrNoConnection is returned when the borrow timeout elapses.
 	ErrNoConnection = errors.New("no connection available in pool")
 
+	// ErrWarmupFailed is returned from DialClient when connections cannot
+	// be established before the warmup timeout elapses.
+	ErrWarmupFailed = errors.New("could not establish connections during warmup")
+
 	defaultBackoff = backoff.NewLinearBackoff(time.Millisecond, time.Millisecond*250, time.Second*5)
 )
 
-// NewClient creates a new Client.
+// NewClient creates a new Client. The address is dialed over TCP unless
+// it is prefixed with a network (e.g. unix:/var/run/redis.sock). If the
+// pool cannot be warmed up, the error is logged and the client is still
+// returned. Use DialClient to handle warmup failures.
 func NewClient(addr string, configs ...ConfigFunc) Client {
+	client, err := dialClient(addr, newConfig(configs))
+	if err != nil {
+		client.logger.Error("Could not warm up connection pool", "error", err)
+	}
+
+	return client
+}
+
+// DialClient creates a new Client. If WithWarmup is supplied, this function
+// blocks until connections to the primary are established. If they cannot be
+// established before the warmup timeout elapses (or if a dial fails), the
+// client is closed and an error is returned.
+func DialClient(addr string, configs ...ConfigFunc) (Client, error) {
+	client, err := dialClient(addr, newConfig(configs))
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func dialClient(addr string, config *clientConfig) (*client, error) {
+	client := newClient(config.dialerFactory([]string{addr}), config, RolePrimary, addr)
+
+	if len(config.readAddrs) > 0 {
+		client.readReplicaClient = newReplicaClient(client, config)
+	}
+
+	if config.warmupTimeout > 0 {
+		n := config.minIdleConns
+		if n < 1 {
+			n = 1
+		}
+
+		if n > config.poolCapacity {
+			n = config.poolCapacity
+		}
+
+		return client, client.warmup(n, config.warmupTimeout)
+	}
+
+	return client, nil
+}
+
+// Create a config with default values and apply the given config functions.
+func newConfig(configs []ConfigFunc) *clientConfig {
 	config := &clientConfig{
 		connectTimeout: time.Second * 5,
 		writeTimeout:   time.Second * 5,
@@ -60,39 +187,92 @@ func NewClient(addr string, configs ...ConfigFunc) Client {
 		breakerFunc:    noopBreakerFunc,
 		backoff:        defaultBackoff,
 		clock:          glock.NewRealClock(),
-		logger:         &nilLogger{},
+		logger:         NilLogger,
+
+		logSampleFirst:      defaultLogSampleFirst,
+		logSampleThereafter: defaultLogSampleThereafter,
+
+		eventBufferSize: defaultEventBufferSize,
+
+		metricsInterval: time.Second * 5,
+
+		replicaCheckInterval:  time.Second * 5,
+		replicaEjectThreshold: 3,
+		replicaFallbackPolicy: FallbackToPrimary,
 	}
 
 	for _, f := range configs {
 		f(config)
 	}
 
+	if _, ok := config.logger.(*nilLogger); !ok {
+		config.logger = newSampledLogger(config.logger, config.logSampleFirst, config.logSampleThereafter, time.Second, config.clock)
+	}
+
+	config.events = newEventBus(config.eventBufferSize, config.clock)
+
 	if config.dialerFactory == nil {
-		config.dialerFactory = makeDefaultDialerFactory(config)
+		replicaCredentials := config.replicaCredentialsProvider
+		if replicaCredentials == nil {
+			replicaCredentials = config.credentialsProvider
+		}
+
+		config.dialerFactory = makeDefaultDialerFactory(config, config.credentialsProvider)
+		config.replicaFactory = makeDefaultDialerFactory(config, replicaCredentials)
+		config.sessionRestorer = makeSessionRestorer(config, config.credentialsProvider)
+		config.replicaSessionRestorer = makeSessionRestorer(config, replicaCredentials)
+	} else {
+		config.replicaFactory = config.dialerFactory
 	}
 
-	return newClient([]string{addr}, config.readAddrs, config)
+	return config
 }
 
-func newClient(addrs, replicaAddrs []string, config *clientConfig) Client {
-	if addrs == nil {
-		return nil
+func newClient(dialer DialFunc, config *clientConfig, role, addr string) *client {
+	hooks := clientHooks(config.hooks)
+
+	var metrics *clientMetrics
+	if config.metrics != nil {
+		// Copy the registered hooks so that each client appends its own
+		// metrics hook without sharing the backing array with another.
+		metrics = newClientMetrics(config.metrics, role, addr)
+		hooks = append(append(clientHooks(nil), hooks...), metrics)
 	}
 
-	pool := NewPool(
-		config.dialerFactory(addrs),
-		config.poolCapacity,
-		config.logger,
-		config.breakerFunc,
-		config.clock,
-	)
+	events := eventEmitter{bus: config.events, role: role, addr: addr}
+	dials := &dialHealth{}
+
+	dialer = makeHookedDialer(dialer, hooks, config.clock)
+	dialer = makeMiddlewareDialer(dialer, config.middleware)
+	pool := newPool(dials.wrap(makeInitializingDialer(dialer, config.onConnect, config)), config, events)
+
+	if metrics != nil {
+		metrics.start(pool, config.metricsInterval, config.clock)
+	}
+
+	restore := config.sessionRestorer
+	if role == RoleReplica {
+		restore = config.replicaSessionRestorer
+	}
 
 	return &client{
-		pool:              pool,
-		readReplicaClient: newClient(replicaAddrs, nil, config),
-		backoff:           config.backoff,
-		clock:             config.clock,
-		logger:            config.logger,
+		pool:          pool,
+		borrowTimeout: config.borrowTimeout,
+		backoff:       config.backoff,
+		clock:         config.clock,
+		logger:        config.logger,
+		hooks:         hooks,
+		metrics:       metrics,
+		tracing:       tracing{tracer: config.tracer, redactor: newRedactor(config.redactions), database: config.database, args: config.traceArgs},
+		commandLog:    newCommandLogger(config),
+		events:        events,
+		dials:         dials,
+		restore:       restore,
+		role:          role,
+		addr:          addr,
+		database:      config.database,
+		waitReplicas:  config.waitReplicas,
+		waitTimeout:   config.waitTimeout,
 	}
 }
 
@@ -112,11 +292,51 @@ func (c *client) Close() {
 		c.readReplicaClient.Close()
 	}
 
+	if c.metrics != nil {
+		c.metrics.stop()
+	}
+
 	c.pool.Close()
+	c.events.close()
+}
+
+func (c *client) CloseContext(ctx context.Context) error {
+	var err error
+	if c.readReplicaClient != nil {
+		err = c.readReplicaClient.CloseContext(ctx)
+	}
+
+	if c.metrics != nil {
+		c.metrics.stop()
+	}
+
+	if poolErr := c.pool.CloseContext(ctx); err == nil {
+		err = poolErr
+	}
+
+	c.events.close()
+	return err
+}
+
+func (c *client) ReadReplicaWithin(maxStaleness time.Duration) Client {
+	if c.readReplicaClient != nil {
+		return c.readReplicaClient.ReadReplicaWithin(maxStaleness)
+	}
+
+	return c
 }
 
 func (c *client) Do(command string, args ...interface{}) (interface{}, error) {
-	return c.withRetry(func() (interface{}, error) { return c.do(command, args) })
+	return c.DoContext(context.Background(), command, args...)
+}
+
+func (c *client) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	ctx, span := c.tracing.startCommand(ctx, command, args)
+	ctx, record := c.commandLog.start(ctx)
+	result, err := c.withRetry(ctx, func(ctx context.Context) (interface{}, error) { return c.do(ctx, command, args) })
+	c.commandLog.finish(record, command, args, err)
+	endSpan(span, err)
+	return result, err
 }
 
 func (c *client) Pipeline() Pipeline {
@@ -126,12 +346,41 @@ func (c *client) Pipeline() Pipeline {
 //
 // Client Helper Functions
 
-func (c *client) withRetry(f retryableFunc) (interface{}, error) {
+// Borrow the given number of connections at once, so that each one must be
+// dialed (or already be idle), then return them to the pool.
+func (c *client) warmup(n int, timeout time.Duration) error {
+	var (
+		deadline = c.clock.Now().Add(timeout)
+		conns    = make([]Conn, 0, n)
+	)
+
+	defer func() {
+		for _, conn := range conns {
+			c.pool.Release(conn)
+		}
+	}()
+
+	for len(conns) < n {
+		conn, err := c.pool.BorrowTimeoutErr(deadline.Sub(c.clock.Now()))
+		if err != nil {
+			return ErrWarmupFailed
+		}
+
+		conns = append(conns, conn)
+	}
+
+	return nil
+}
+
+func (c *client) withRetry(ctx context.Context, f retryableFunc) (interface{}, error) {
 	// Get a copy of the backoff
 	backoff := c.backoff.Clone()
+	reauthenticated := false
 
-	for {
-		result, err := f()
+	for attempt := 1; ; attempt++ {
+		attemptCtx, span := c.tracing.startAttempt(ctx, c.addr, attempt)
+		result, err := f(attemptCtx)
+		endSpan(span, err)
 
 		// Stop retry loop if we either succeeded or encountered a non-recoverable
 		// error (non-network error). We don't want to retry protocol or redis logic
@@ -141,99 +390,187 @@ func (c *client) withRetry(f retryableFunc) (interface{}, error) {
 			return result, nil
 		}
 
-		if _, ok := err.(connErr); !ok {
+		// Credentials may have been revoked since the connection was dialed,
+		// so retry once on a new connection. If the fresh credentials are
+		// also rejected, the failure is deterministic.
+
+		if authErr, ok := err.(reauthErr); ok {
+			if reauthenticated {
+				return result, authErr.error
+			}
+
+			reauthenticated = true
+		} else if _, ok := err.(connErr); !ok {
 			return result, err
 		}
 
 		// Log error here so it's not silently dropped
-		c.logger.Printf("Received error from command, retrying (%s)", err.Error())
+		c.logger.Warn("Received error from command, retrying", "attempt", attempt, "error", err)
+		c.commandLog.retried(ctx)
+		c.events.emit(Event{Type: RetryAttempt, Attempt: attempt, Err: err})
 
 		// Backoff, don't thrash the pool
-		<-c.clock.After(backoff.NextInterval())
+		select {
+		case <-c.clock.After(backoff.NextInterval()):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 }
 
 // Invoke a command and release the connection back to the pool.
-func (c *client) do(command string, args []interface{}) (interface{}, error) {
-	conn, ok := c.timedBorrow()
-	if !ok {
-		return nil, ErrNoConnection
+func (c *client) do(ctx context.Context, command string, args []interface{}) (interface{}, error) {
+	if err := checkCommand(command, args); err != nil {
+		return nil, err
 	}
 
-	result, err := conn.Do(command, args...)
-	c.release(conn, err)
-	return result, err
+	return c.hooks.do(ctx, c.clock, command, args, func(ctx context.Context) (interface{}, error) {
+		conn, err := c.timedBorrow(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := conn.Do(command, args...)
+		if err == nil {
+			reset := sessionReset{}
+			reset.add(command, args)
+			c.release(conn, c.resetSession(conn, reset))
+			return result, nil
+		}
+
+		c.release(conn, err)
+		return result, err
+	})
 }
 
 // Invoke a series of commands wrapped in MULTI and EXEC commands
 // and release the connection back to the pool. Will retry on error.
-func (c *client) pipeline(commands []commandPair) (interface{}, error) {
-	return c.withRetry(func() (interface{}, error) { return c.doPipeline(commands) })
+func (c *client) pipeline(ctx context.Context, commands []commandPair) (interface{}, error) {
+	ctx, span := c.tracing.startPipeline(ctx, commands)
+	ctx, record := c.commandLog.start(ctx)
+	result, err := c.withRetry(ctx, func(ctx context.Context) (interface{}, error) { return c.doPipeline(ctx, commands) })
+	c.commandLog.finishPipeline(record, commands, err)
+	endSpan(span, err)
+	return result, err
 }
 
 // Invoke a series of commands wrapped in MULTI and EXEC commands
 // and release the connection back to the pool.
-func (c *client) doPipeline(commands []commandPair) (interface{}, error) {
-	conn, ok := c.timedBorrow()
-	if !ok {
-		return nil, ErrNoConnection
-	}
-
-	if err := conn.Send("MULTI"); err != nil {
-		c.release(conn, err)
+func (c *client) doPipeline(ctx context.Context, commands []commandPair) (interface{}, error) {
+	if err := checkCommands(commands); err != nil {
 		return nil, err
 	}
 
-	for _, command := range commands {
-		if err := conn.Send(command.command, command.args...); err != nil {
+	return c.hooks.pipeline(ctx, c.clock, commands, func(ctx context.Context) (interface{}, error) {
+		conn, err := c.timedBorrow(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := conn.Send("MULTI"); err != nil {
 			c.release(conn, err)
 			return nil, err
 		}
+
+		for _, command := range commands {
+			if err := conn.Send(command.command, command.args...); err != nil {
+				c.release(conn, err)
+				return nil, err
+			}
+		}
+
+		result, err := conn.Do("EXEC")
+		if err == nil {
+			reset := sessionReset{}
+			for _, command := range commands {
+				reset.add(command.command, command.args)
+			}
+
+			reset.add("EXEC", nil)
+			c.release(conn, c.resetSession(conn, reset))
+			return result, nil
+		}
+
+		c.release(conn, err)
+		return result, err
+	})
+}
+
+// Borrow a connection and invoke a command. This is used to determine
+// the health and state of a remote server, so the borrow will not block
+// for longer than the given timeout and the command is never retried.
+func (c *client) doTimeout(timeout time.Duration, command string, args ...interface{}) (interface{}, error) {
+	conn, err := c.pool.BorrowTimeoutErr(timeout)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := conn.Do("EXEC")
+	result, err := conn.Do(command, args...)
 	c.release(conn, err)
 	return result, err
 }
 
 // Borrows and logs the time it took to return from blocking on the
-// pool's borrow method.
-func (c *client) timedBorrow() (Conn, bool) {
+// pool's borrow method. The borrow is wrapped in the registered hooks.
+func (c *client) timedBorrow(ctx context.Context) (Conn, error) {
+	ctx, span := c.tracing.startBorrow(ctx)
+
+	start := c.clock.Now()
 	watch := stopwatch.Start()
-	conn, ok := c.borrow()
+	conn, err := c.hooks.borrow(ctx, c.clock, func() (Conn, error) { return c.borrow(ctx) })
 	watch.Stop()
+	endSpan(span, err)
+	c.commandLog.waited(ctx, start)
+
 	elapsed := watch.Milliseconds()
 
-	if ok {
-		c.logger.Printf("Received connection after %v", elapsed)
+	// Both outcomes are logged at the debug level so that they are sampled:
+	// a failed borrow is returned to the caller, and an exhausted pool would
+	// otherwise log a message for every command.
+
+	if err == nil {
+		c.logger.Debug("Received connection", "elapsed_ms", elapsed)
 	} else {
-		c.logger.Printf("Could not borrow connection after %v", elapsed)
+		c.logger.Debug("Could not borrow connection", "elapsed_ms", elapsed, "error", err)
 	}
 
-	return conn, ok
+	return conn, err
 }
 
 // Borrows from the pool using the correct method (depending on if
-// a borrow timeout was configured on this client).
-func (c *client) borrow() (Conn, bool) {
-	if c.borrowTimeout == nil {
-		return c.pool.Borrow()
+// a borrow timeout was configured on this client). If the context has
+// a deadline, the borrow does not block past it.
+func (c *client) borrow(ctx context.Context) (Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return c.pool.BorrowTimeout(*c.borrowTimeout)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		if c.borrowTimeout == nil {
+			return c.pool.BorrowErr()
+		}
+
+		return c.pool.BorrowTimeoutErr(*c.borrowTimeout)
+	}
+
+	timeout := time.Until(deadline)
+	if c.borrowTimeout != nil && *c.borrowTimeout < timeout {
+		timeout = *c.borrowTimeout
+	}
+
+	return c.pool.BorrowTimeoutErr(timeout)
 }
 
-// Close the connection on error and release it back to the pool.
-// Bad connections never go back to the pool, so in the case that
-// there was an error we return nil (if we do not do this on some
-// code path then the capacity of the pool permanently decreases).
+// Discard the connection on error and release it back to the pool
+// otherwise. Bad connections never go back to the pool, but the pool
+// must still be told about them (if we do not do this on some code
+// path then the capacity of the pool permanently decreases).
 func (c *client) release(conn Conn, err error) {
 	if err != nil {
-		if err := conn.Close(); err != nil {
-			c.logger.Printf("Could not close connection (%s)", err.Error())
-		}
-
-		conn = nil
+		c.pool.Discard(conn)
+		c.events.emit(Event{Type: ConnectionDiscarded, Err: err})
+		return
 	}
 
 	c.pool.Release(conn)
@@ -1,6 +1,11 @@
 package deepjoy
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/efritz/backoff"
@@ -17,22 +22,271 @@ func WithDialerFactory(dialerFactory DialerFactory) ConfigFunc {
 	return func(c *clientConfig) { c.dialerFactory = dialerFactory }
 }
 
+// WithNetDialer sets the function used by the default dialer to create
+// network connections. This can be used to tune keepalives or the source
+// address via a net.Dialer, or to dial an in-process connection in tests.
+func WithNetDialer(dialer NetDialFunc) ConfigFunc {
+	return func(c *clientConfig) { c.netDialer = dialer }
+}
+
+// WithOnConnect adds a function which is invoked on each new connection
+// after authentication and database selection, and before the connection
+// is handed to a borrower. Functions are invoked in the order in which they
+// are added. If a function returns an error, the connection is closed and
+// the dial is treated as a failure by the circuit breaker.
+func WithOnConnect(f OnConnectFunc) ConfigFunc {
+	return func(c *clientConfig) { c.onConnect = append(c.onConnect, f) }
+}
+
+// WithClientName adds a connection initialization function which sets the
+// name of each new connection to the given service name followed by the
+// hostname and process id (e.g. checkout/web-1/8213). Spaces and other
+// characters which Redis does not allow in a client name are replaced with
+// dashes. The name is visible in the output of CLIENT LIST.
+func WithClientName(service string) ConfigFunc {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	// Client names may only contain printable characters other than spaces
+	name := strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return '-'
+		}
+
+		return r
+	}, fmt.Sprintf("%s/%s/%d", service, hostname, os.Getpid()))
+
+	return WithOnConnect(func(ctx context.Context, conn Conn) error {
+		_, err := conn.Do("CLIENT", "SETNAME", name)
+		return err
+	})
+}
+
+// WithConnMiddleware adds a function which wraps each new connection. This
+// can be used to intercept the commands sent over the connection, including
+// the commands sent by connection initialization hooks. Middleware is applied
+// in the order in which it is added, so that the first middleware added is
+// the outermost and sees each command first.
+func WithConnMiddleware(middleware ConnMiddleware) ConfigFunc {
+	return func(c *clientConfig) { c.middleware = append(c.middleware, middleware) }
+}
+
+// WithClientHook adds a hook which is invoked around each command, pipeline,
+// dial, and borrow made by the client. Before callbacks are invoked in the
+// order in which hooks are added, and after callbacks in the reverse order.
+func WithClientHook(hook ClientHook) ConfigFunc {
+	return func(c *clientConfig) { c.hooks = append(c.hooks, hook) }
+}
+
+// WithMetrics sets the sink to which the client reports command latency and
+// outcomes, borrow wait time, dials, and pool gauges. Measurements of the
+// primary and of each read replica are labeled with the role and address of
+// the server. No measurements are taken if no sink is configured.
+func WithMetrics(metrics Metrics) ConfigFunc {
+	return func(c *clientConfig) { c.metrics = metrics }
+}
+
+// WithMetricsInterval sets the interval at which pool gauges are published
+// to the metrics sink. The default is five seconds.
+func WithMetricsInterval(interval time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.metricsInterval = interval }
+}
+
+// WithTracer sets the tracer used to create a span for each command and
+// pipeline. Each span covers every attempt of the operation, and has a
+// child span for each attempt and for each connection borrow. No spans
+// are created if no tracer is configured.
+func WithTracer(tracer Tracer) ConfigFunc {
+	return func(c *clientConfig) { c.tracer = tracer }
+}
+
+// WithTracedArgs attaches the command and its redacted arguments to each
+// command and pipeline span. See WithArgRedaction.
+func WithTracedArgs() ConfigFunc {
+	return func(c *clientConfig) { c.traceArgs = true }
+}
+
+// WithArgRedaction sets the function which formats the arguments of the
+// given command in spans and command log entries. By default, only the
+// first argument (usually the key) of each command is kept, and none of
+// the arguments of commands which may carry credentials (e.g. AUTH) are
+// kept. Use KeepArgs(2) to also keep the value of a SET command, or
+// RedactAll to hide the key of a command.
+func WithArgRedaction(command string, redactor ArgRedactor) ConfigFunc {
+	return func(c *clientConfig) {
+		if c.redactions == nil {
+			c.redactions = map[string]ArgRedactor{}
+		}
+
+		c.redactions[command] = redactor
+	}
+}
+
+// WithSlowLog records each command and pipeline which takes at least the
+// given duration (including borrowing a connection and retrying) to the
+// given command log. See NewCommandLogBuffer.
+func WithSlowLog(threshold time.Duration, log CommandLog) ConfigFunc {
+	return func(c *clientConfig) {
+		c.slowLogThreshold = threshold
+		c.slowLog = log
+	}
+}
+
+// WithAuditLog records each command matching the given filter to the given
+// command log, whether or not it succeeds. A pipeline is recorded if any of
+// its commands match. A nil filter matches every command. See WriteCommands.
+func WithAuditLog(filter CommandFilter, log CommandLog) ConfigFunc {
+	return func(c *clientConfig) {
+		c.auditFilter = filter
+		c.auditLog = log
+	}
+}
+
+// WithEventBufferSize sets the capacity of the channel of each subscription
+// returned from the client's Events method. Events are dropped for a
+// subscription whose channel is full. The default is 64.
+func WithEventBufferSize(size int) ConfigFunc {
+	return func(c *clientConfig) { c.eventBufferSize = size }
+}
+
 // WithReadReplicaAddrs sets the addresses of the client returned
 // by client's the ReadReplica() method.
 func WithReadReplicaAddrs(addrs ...string) ConfigFunc {
 	return func(c *clientConfig) { c.readAddrs = addrs }
 }
 
+// WithReplicaHealthCheckInterval sets the interval at which each read
+// replica is sent a PING (default is 5 seconds). A non-positive interval
+// disables active health checks.
+func WithReplicaHealthCheckInterval(interval time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.replicaCheckInterval = interval }
+}
+
+// WithReplicaEjectionThreshold sets the number of consecutive failures
+// after which a read replica is removed from the rotation (default is 3).
+func WithReplicaEjectionThreshold(threshold int) ConfigFunc {
+	return func(c *clientConfig) { c.replicaEjectThreshold = threshold }
+}
+
+// WithReplicaFallbackPolicy sets the behavior of the read replica client
+// when no read replica is healthy (default is FallbackToPrimary).
+func WithReplicaFallbackPolicy(policy ReplicaFallbackPolicy) ConfigFunc {
+	return func(c *clientConfig) { c.replicaFallbackPolicy = policy }
+}
+
+// WithReplicaEventHandler sets a function to invoke when a read replica
+// is ejected from or restored to the rotation. The handler receives the
+// ReplicaStateChanged events published to the client's event bus on its own
+// goroutine, so events are dropped if the handler falls more than the event
+// buffer size behind (see WithEventBufferSize).
+func WithReplicaEventHandler(handler ReplicaEventHandler) ConfigFunc {
+	return func(c *clientConfig) { c.replicaEventHandler = handler }
+}
+
+// WithReplicaMaxLag sets the maximum estimated replication lag of a read
+// replica. Replicas lagging further behind the primary are removed from the
+// rotation until they catch up. The default is to not exclude replicas based
+// on replication lag.
+func WithReplicaMaxLag(lag time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.replicaMaxLag = lag }
+}
+
+// WithConsistencyWait sets the number of replicas and the timeout passed to
+// a WAIT command issued after each command run via DoWithToken. By default,
+// no WAIT command is issued.
+func WithConsistencyWait(replicas int, timeout time.Duration) ConfigFunc {
+	return func(c *clientConfig) {
+		c.waitReplicas = replicas
+		c.waitTimeout = timeout
+	}
+}
+
 // WithPassword sets the password (default is "").
 func WithPassword(password string) ConfigFunc {
 	return func(c *clientConfig) { c.password = password }
 }
 
+// WithCredentialsProvider sets a function which returns the username and
+// password used to authenticate each new connection. The provider is invoked
+// each time a connection is dialed and takes precedence over WithPassword.
+// If the server rejects the credentials, the provider is invoked again and
+// the connection is redialed.
+func WithCredentialsProvider(provider CredentialsProvider) ConfigFunc {
+	return func(c *clientConfig) { c.credentialsProvider = provider }
+}
+
+// WithReplicaCredentialsProvider sets the credentials provider used to
+// authenticate connections to read replicas (the default is to use the
+// same credentials as the primary).
+func WithReplicaCredentialsProvider(provider CredentialsProvider) ConfigFunc {
+	return func(c *clientConfig) { c.replicaCredentialsProvider = provider }
+}
+
 // WithDatabase sets the database index (default is 0).
 func WithDatabase(database int) ConfigFunc {
 	return func(c *clientConfig) { c.database = database }
 }
 
+// WithTLS enables TLS for connections made by the default dialer. The
+// remote server's certificate is verified against the system roots unless
+// a CA bundle or another TLS option is also supplied.
+func WithTLS() ConfigFunc {
+	return func(c *clientConfig) { c.useTLS = true }
+}
+
+// WithTLSConfig enables TLS and sets the base TLS configuration used by
+// the default dialer. Other TLS options take precedence over the values
+// set in the given configuration.
+func WithTLSConfig(config *tls.Config) ConfigFunc {
+	return func(c *clientConfig) {
+		c.useTLS = true
+		c.tlsConfig = config
+	}
+}
+
+// WithTLSCAFile enables TLS and sets the path of a PEM-encoded bundle of
+// certificate authorities used to verify the remote server. The file is
+// reloaded when it changes.
+func WithTLSCAFile(path string) ConfigFunc {
+	return func(c *clientConfig) {
+		c.useTLS = true
+		c.tlsCAFile = path
+	}
+}
+
+// WithTLSClientCertFiles enables TLS and sets the paths of a PEM-encoded
+// client certificate and private key presented to the remote server. The
+// files are reloaded when they change, so that rotated certificates are
+// used for new connections without recreating the client.
+func WithTLSClientCertFiles(certFile, keyFile string) ConfigFunc {
+	return func(c *clientConfig) {
+		c.useTLS = true
+		c.tlsCertFile = certFile
+		c.tlsKeyFile = keyFile
+	}
+}
+
+// WithTLSServerName enables TLS and sets the server name used to verify
+// the remote server's certificate and sent as the SNI extension (default
+// is the host of the address being dialed).
+func WithTLSServerName(name string) ConfigFunc {
+	return func(c *clientConfig) {
+		c.useTLS = true
+		c.tlsServerName = name
+	}
+}
+
+// WithTLSInsecureSkipVerify enables TLS and disables verification of the
+// remote server's certificate. This should only be used in tests.
+func WithTLSInsecureSkipVerify() ConfigFunc {
+	return func(c *clientConfig) {
+		c.useTLS = true
+		c.tlsSkipVerify = true
+	}
+}
+
 // WithConnectTimeout sets the connect timeout for new connections
 // (default is 5 seconds).
 func WithConnectTimeout(timeout time.Duration) ConfigFunc {
@@ -57,6 +311,104 @@ func WithPoolCapacity(capacity int) ConfigFunc {
 	return func(c *clientConfig) { c.poolCapacity = capacity }
 }
 
+// WithMaxIdleTime sets the maximum time a connection may remain idle in
+// the pool before it is closed. The default is to not close idle connections.
+func WithMaxIdleTime(timeout time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.maxIdleTime = timeout }
+}
+
+// WithMaxConnLifetime sets the maximum time since a connection was dialed
+// after which it is closed rather than reused. The default is to reuse
+// connections indefinitely.
+func WithMaxConnLifetime(lifetime time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.maxLifetime = lifetime }
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept in the
+// pool. Connections released to a pool which already holds this many idle
+// connections are closed. The default is to keep up to the pool capacity.
+func WithMaxIdleConns(n int) ConfigFunc {
+	return func(c *clientConfig) { c.maxIdleConns = n }
+}
+
+// WithMinIdleConns sets the minimum number of idle connections kept in the
+// pool. These connections are dialed in the background when the pool is
+// created, and are replenished as connections are closed. The default is
+// to dial connections only when they are needed.
+func WithMinIdleConns(n int) ConfigFunc {
+	return func(c *clientConfig) { c.minIdleConns = n }
+}
+
+// WithWarmup sets the maximum time DialClient blocks while establishing the
+// minimum number of idle connections (or a single connection, if no minimum
+// is set). By default, DialClient does not establish any connections.
+func WithWarmup(timeout time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.warmupTimeout = timeout }
+}
+
+// WithBorrowHealthCheck sets the duration after which an idle connection
+// is sent a PING before it is borrowed. A connection which fails the PING
+// is closed and replaced by a new connection. The default is to not check
+// idle connections.
+func WithBorrowHealthCheck(idle time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.borrowCheckIdle = idle }
+}
+
+// WithMaxWaiters sets the maximum number of borrowers which may wait for a
+// connection at once. When this many borrowers are already waiting, further
+// borrows fail immediately with ErrPoolExhausted so that an overloaded
+// service sheds load rather than queuing without bound. The default is to
+// not limit the number of waiting borrowers.
+func WithMaxWaiters(n int) ConfigFunc {
+	return func(c *clientConfig) { c.maxWaiters = n }
+}
+
+// WithMaxConcurrentDials sets the maximum number of connections which may
+// be dialed at once. While a borrower waits for a dial to begin, a dial made
+// by another borrower which is rejected by the circuit breaker fails its dial
+// as well. Other dial failures are not shared. The default is to
+// dial as many connections at once as the pool capacity allows.
+func WithMaxConcurrentDials(n int) ConfigFunc {
+	return func(c *clientConfig) { c.maxConcurrentDials = n }
+}
+
+// WithLeakDetection enables a debug mode in which the stack of each borrow
+// is recorded. Connections which are held for longer than the threshold are
+// logged along with the stack of the borrower, as are connections which are
+// released more than once or which were not borrowed from the pool. Borrows
+// which are still outstanding when the close deadline elapses (and which are
+// then closed by the pool) are also logged. Recording
+// stacks is expensive, so this should not be enabled in production.
+func WithLeakDetection(threshold time.Duration) ConfigFunc {
+	return func(c *clientConfig) { c.leakThreshold = threshold }
+}
+
+// WithAdaptivePoolCapacity enables adaptive sizing of the connection pool.
+// The pool capacity is periodically grown when borrowers wait longer than
+// the target duration on average for a connection, and shrunk when idle
+// connections are not used (see WithAdaptiveInterval). The capacity is kept
+// between the given bounds, and starts at the value set by WithPoolCapacity.
+func WithAdaptivePoolCapacity(min, max int, targetWait time.Duration) ConfigFunc {
+	return func(c *clientConfig) {
+		c.adaptiveMin = min
+		c.adaptiveMax = max
+		c.adaptiveTargetWait = targetWait
+	}
+}
+
+// WithAdaptiveInterval sets how often an adaptive pool is resized (default
+// is one second), and how long a connection must sit idle before the pool
+// shrinks to close it (default is 30 seconds). The pool shrinks only after
+// no borrower has waited for three consecutive intervals, and then by at
+// most a quarter of its capacity at a time. This option has no effect
+// unless WithAdaptivePoolCapacity is also given.
+func WithAdaptiveInterval(interval, minIdleTime time.Duration) ConfigFunc {
+	return func(c *clientConfig) {
+		c.adaptiveInterval = interval
+		c.adaptiveIdleTime = minIdleTime
+	}
+}
+
 // WithRetryBackoff sets the circuit backoff prototype to use when
 // retrying a redis command after a non-protocol network error.
 func WithRetryBackoff(backoff backoff.Backoff) ConfigFunc {
@@ -85,8 +437,31 @@ func WithBorrowTimeout(timeout time.Duration) ConfigFunc {
 	return func(c *clientConfig) { c.borrowTimeout = &timeout }
 }
 
-// WithLogger sets the logger instance (the default will use Go's
-// builtin logging library).
+// WithLogger sets the logger instance (the default discards all messages).
+// If the logger also implements LeveledLogger it is used directly. Otherwise,
+// debug messages are discarded and all other messages are formatted with
+// their level and fields. Use WithLeveledLogger and NewPrintfLogger to also
+// write debug messages to a Printf logger.
 func WithLogger(logger Logger) ConfigFunc {
+	return func(c *clientConfig) { c.logger = leveledLogger(logger) }
+}
+
+// WithLeveledLogger sets the structured logger instance. See NewSlogLogger
+// to write to a log/slog logger.
+func WithLeveledLogger(logger LeveledLogger) ConfigFunc {
 	return func(c *clientConfig) { c.logger = logger }
 }
+
+// WithLogSampling limits the rate of debug messages, some of which are logged
+// for every command. Within each second, the first messages with the same text
+// are logged, and thereafter only every nth message is logged. Messages at
+// other levels are never sampled. By default, the first 10 messages are logged
+// and then every 100th. A non-positive value of first disables sampling.
+// Sampling applies to Printf loggers adapted by NewPrintfLogger, but a Printf
+// logger set with WithLogger discards debug messages, so it is unaffected.
+func WithLogSampling(first, thereafter int) ConfigFunc {
+	return func(c *clientConfig) {
+		c.logSampleFirst = first
+		c.logSampleThereafter = thereafter
+	}
+}
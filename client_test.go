@@ -1,14 +1,17 @@
 package deepjoy
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"github.com/aphistic/sweet"
 	"github.com/efritz/glock"
 	. "github.com/efritz/go-mockgen/matchers"
+	"github.com/efritz/overcurrent"
 	. "github.com/onsi/gomega"
 
 	"github.com/efritz/deepjoy/mocks"
@@ -84,6 +87,22 @@ func (s *ClientSuite) TestClose(t sweet.T) {
 	Expect(pool.CloseFunc).To(BeCalled())
 }
 
+func (s *ClientSuite) TestCloseContext(t sweet.T) {
+	var (
+		pool1   = mocks.NewMockPool()
+		pool2   = mocks.NewMockPool()
+		client1 = makeClient(pool1, nil)
+		client2 = makeClient(pool2, nil)
+	)
+
+	pool2.CloseContextFunc.SetDefaultReturn(context.DeadlineExceeded)
+
+	client1.readReplicaClient = client2
+	Expect(client1.CloseContext(context.Background())).To(Equal(context.DeadlineExceeded))
+	Expect(pool1.CloseContextFunc).To(BeCalled())
+	Expect(pool2.CloseContextFunc).To(BeCalled())
+}
+
 func (s *ClientSuite) TestDo(t sweet.T) {
 	var (
 		pool = mocks.NewMockPool()
@@ -125,7 +144,29 @@ func (s *ClientSuite) TestDoError(t sweet.T) {
 
 	_, err := c.Do("upper", "bar", "baz", "quux")
 	Expect(err).To(MatchError("utoh"))
-	Expect(pool.ReleaseFunc).To(BeCalledWith(BeNil()))
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+}
+
+func (s *ClientSuite) TestDoErrorForgetsConnection(t sweet.T) {
+	var (
+		dial = func() (Conn, error) {
+			conn := mocks.NewMockConn()
+			conn.DoFunc.SetDefaultReturn(nil, errors.New("WRONGTYPE"))
+			return conn, nil
+		}
+
+		pool = NewPool(dial, 5, NilLogger, noopBreakerFunc, nil)
+		c    = makeClient(pool, nil)
+	)
+
+	for i := 0; i < 100; i++ {
+		_, err := c.Do("incr", "foo")
+		Expect(err).To(MatchError("WRONGTYPE"))
+	}
+
+	Expect(trackedConns(pool)).To(Equal(0))
+	Expect(pool.Stats().ErrorClosed).To(Equal(uint64(100)))
+	Expect(pool.CloseContext(context.Background())).To(BeNil())
 }
 
 func (s *ClientSuite) TestDoRetryableError(t sweet.T) {
@@ -150,10 +191,69 @@ func (s *ClientSuite) TestDoRetryableError(t sweet.T) {
 	result, err := c.Do("upper", "bar", "baz", "quux")
 	Expect(err).To(BeNil())
 	Expect(result).To(Equal([]string{"BAR", "BAZ", "QUUX"}))
-	Expect(pool.ReleaseFunc).To(BeCalledWith(BeNil()))
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn1))
 	Expect(pool.ReleaseFunc).To(BeCalledWith(conn2))
 }
 
+func (s *ClientSuite) TestDoWithToken(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.PushReturn("OK", nil)
+	conn.DoFunc.PushReturn([]byte("role:master\r\nmaster_repl_offset:3621\r\n"), nil)
+
+	result, token, err := c.DoWithToken("set", "foo", "bar")
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal("OK"))
+	Expect(token).To(Equal(ConsistencyToken{Offset: 3621}))
+	Expect(conn.DoFunc).To(BeCalledWith("set", "foo", "bar"))
+	Expect(conn.DoFunc).To(BeCalledWith("INFO", "replication"))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+}
+
+func (s *ClientSuite) TestDoWithTokenWait(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	c.waitReplicas = 2
+	c.waitTimeout = time.Millisecond * 250
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.PushReturn("OK", nil)
+	conn.DoFunc.PushReturn(int64(1), nil)
+	conn.DoFunc.PushReturn([]byte("role:master\r\nmaster_repl_offset:3621\r\n"), nil)
+
+	_, token, err := c.DoWithToken("set", "foo", "bar")
+	Expect(err).To(BeNil())
+	Expect(token).To(Equal(ConsistencyToken{Offset: 3621, Replicas: 1}))
+	Expect(conn.DoFunc).To(BeCalledWith("WAIT", 2, int64(250)))
+}
+
+func (s *ClientSuite) TestDoWithTokenOffsetError(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.PushReturn(int64(1), nil)
+	conn.DoFunc.PushReturn(nil, connErr{io.EOF})
+
+	// Does not retry the successful command
+	result, _, err := c.DoWithToken("incr", "foo")
+	Expect(err).To(MatchError("could not determine replication offset (EOF)"))
+	Expect(result).To(Equal(int64(1)))
+	Expect(pool.BorrowFunc).To(BeCalledOnce())
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+}
+
 func (s *ClientSuite) TestPipeline(t sweet.T) {
 	var (
 		pool = mocks.NewMockPool()
@@ -181,6 +281,20 @@ func (s *ClientSuite) TestPipeline(t sweet.T) {
 	Expect(conn.DoFunc).To(BeCalledWith("EXEC"))
 }
 
+func (s *ClientSuite) TestDoPoolExhausted(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowErrFunc.SetDefaultReturn(nil, ErrPoolExhausted)
+
+	_, err := c.Do("upper", "bar")
+	Expect(err).To(Equal(ErrPoolExhausted))
+	Expect(pool.BorrowErrFunc).To(BeCalledOnce())
+	Expect(pool.ReleaseFunc).NotTo(BeCalled())
+}
+
 func (s *ClientSuite) TestPipelineNoConnection(t sweet.T) {
 	var (
 		pool = mocks.NewMockPool()
@@ -212,7 +326,7 @@ func (s *ClientSuite) TestPipelineError(t sweet.T) {
 	_, err := pipeline.Run()
 
 	Expect(err).To(MatchError("utoh"))
-	Expect(pool.ReleaseFunc).To(BeCalledWith(BeNil()))
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
 }
 
 func (s *ClientSuite) TestPipelineRetryableError(t sweet.T) {
@@ -242,7 +356,7 @@ func (s *ClientSuite) TestPipelineRetryableError(t sweet.T) {
 
 	Expect(err).To(BeNil())
 	Expect(result).To(Equal([]int{1, 2, 3, 4}))
-	Expect(pool.ReleaseFunc).To(BeCalledWith(BeNil()))
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn1))
 	Expect(pool.ReleaseFunc).To(BeCalledWith(conn2))
 }
 
@@ -275,14 +389,290 @@ func (s *ClientSuite) TestPipelineRetryableErrorAfterMulti(t sweet.T) {
 	Expect(err).To(BeNil())
 	Expect(result).To(Equal([]int{1, 2, 3, 4}))
 
-	Expect(pool.ReleaseFunc).To(BeCalledWith(BeNil()))
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn1))
 	Expect(pool.ReleaseFunc).To(BeCalledWith(conn2))
 }
 
+func (s *ClientSuite) TestOnConnect(t sweet.T) {
+	var (
+		conn  = mocks.NewMockConn()
+		calls = []string{}
+	)
+
+	conn.DoFunc.SetDefaultReturn("OK", nil)
+
+	client := NewClient(
+		"master",
+		WithLogger(NilLogger),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) { return conn, nil }
+		}),
+		WithOnConnect(func(ctx context.Context, c Conn) error {
+			_, ok := ctx.Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(c).To(Equal(conn))
+			calls = append(calls, "first")
+			return nil
+		}),
+		WithOnConnect(func(ctx context.Context, c Conn) error {
+			calls = append(calls, "second")
+			return nil
+		}),
+	)
+	defer client.Close()
+
+	Expect(client.Do("ping")).To(Equal("OK"))
+	Expect(client.Do("ping")).To(Equal("OK"))
+	Expect(calls).To(Equal([]string{"first", "second"}))
+}
+
+func (s *ClientSuite) TestOnConnectError(t sweet.T) {
+	var (
+		conn        = mocks.NewMockConn()
+		breakerErrs = []error{}
+	)
+
+	client := NewClient(
+		"master",
+		WithLogger(NilLogger),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) { return conn, nil }
+		}),
+		func(c *clientConfig) {
+			c.breakerFunc = func(f overcurrent.BreakerFunc) error {
+				err := f(context.Background())
+				breakerErrs = append(breakerErrs, err)
+				return err
+			}
+		},
+		WithOnConnect(func(ctx context.Context, c Conn) error {
+			return fmt.Errorf("utoh")
+		}),
+	)
+	defer client.Close()
+
+	_, err := client.Do("ping")
+	Expect(err).To(Equal(ErrNoConnection))
+	Expect(breakerErrs).To(Equal([]error{fmt.Errorf("utoh")}))
+	Expect(conn.CloseFunc).To(BeCalledOnce())
+	Expect(conn.DoFunc).NotTo(BeCalled())
+}
+
+func (s *ClientSuite) TestClientName(t sweet.T) {
+	conn := mocks.NewMockConn()
+	conn.DoFunc.SetDefaultReturn("OK", nil)
+
+	client := NewClient(
+		"master",
+		WithLogger(NilLogger),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) { return conn, nil }
+		}),
+		WithClientName("checkout api"),
+	)
+	defer client.Close()
+
+	hostname, _ := os.Hostname()
+	Expect(client.Do("ping")).To(Equal("OK"))
+	Expect(conn.DoFunc).To(BeCalledWith(
+		"CLIENT",
+		"SETNAME",
+		fmt.Sprintf("checkout-api/%s/%d", hostname, os.Getpid()),
+	))
+}
+
+func (s *ClientSuite) TestClientNameNonPrintable(t sweet.T) {
+	conn := mocks.NewMockConn()
+	conn.DoFunc.SetDefaultReturn("OK", nil)
+
+	client := NewClient(
+		"master",
+		WithLogger(NilLogger),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) { return conn, nil }
+		}),
+		WithClientName("checkout\r\n\tapi\x7fcafé"),
+	)
+	defer client.Close()
+
+	hostname, _ := os.Hostname()
+	Expect(client.Do("ping")).To(Equal("OK"))
+	Expect(conn.DoFunc).To(BeCalledWith(
+		"CLIENT",
+		"SETNAME",
+		fmt.Sprintf("checkout---api-caf-/%s/%d", hostname, os.Getpid()),
+	))
+}
+
+func (s *ClientSuite) TestDialClientWarmup(t sweet.T) {
+	dialed := make(chan struct{}, 10)
+
+	client, err := DialClient(
+		"master",
+		WithLogger(NilLogger),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) {
+				dialed <- struct{}{}
+				return mocks.NewMockConn(), nil
+			}
+		}),
+		WithWarmup(time.Second),
+	)
+
+	Expect(err).To(BeNil())
+	Expect(dialed).To(Receive())
+	client.Close()
+}
+
+func (s *ClientSuite) TestDialClientWarmupFailure(t sweet.T) {
+	client, err := DialClient(
+		"master",
+		WithLogger(NilLogger),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) { return nil, fmt.Errorf("utoh") }
+		}),
+		WithWarmup(time.Second),
+	)
+
+	Expect(client).To(BeNil())
+	Expect(err).To(Equal(ErrWarmupFailed))
+}
+
+func (s *ClientSuite) TestWarmupTimeout(t sweet.T) {
+	var (
+		pool  = mocks.NewMockPool()
+		conn  = mocks.NewMockConn()
+		clock = glock.NewMockClock()
+		c     = makeClient(pool, clock)
+	)
+
+	pool.BorrowTimeoutFunc.PushReturn(conn, true)
+	pool.BorrowTimeoutFunc.PushReturn(conn, true)
+	pool.BorrowTimeoutFunc.PushReturn(nil, false)
+
+	Expect(c.warmup(3, time.Second)).To(Equal(ErrWarmupFailed))
+	Expect(pool.BorrowTimeoutFunc).To(BeCalledN(3))
+	Expect(pool.ReleaseFunc).To(BeCalledN(2))
+}
+
+func (s *ClientSuite) TestStats(t sweet.T) {
+	var (
+		primaryPool  = mocks.NewMockPool()
+		replicaPool1 = mocks.NewMockPool()
+		replicaPool2 = mocks.NewMockPool()
+		primary      = makeClient(primaryPool, nil)
+	)
+
+	primaryPool.StatsFunc.SetDefaultReturn(PoolStats{Capacity: 10, Open: 4, Dials: 6})
+	replicaPool1.StatsFunc.SetDefaultReturn(PoolStats{Capacity: 5, Open: 2, Dials: 3})
+	replicaPool2.StatsFunc.SetDefaultReturn(PoolStats{Capacity: 5, Open: 1, Dials: 1})
+
+	primary.readReplicaClient = &replicaClient{
+		primary: primary,
+		replicas: []*replica{
+			{addr: "r1", client: makeClient(replicaPool1, nil)},
+			{addr: "r2", client: makeClient(replicaPool2, nil)},
+		},
+	}
+
+	expected := ClientStats{
+		Primary: PoolStats{Capacity: 10, Open: 4, Dials: 6},
+		Replicas: map[string]PoolStats{
+			"r1": {Capacity: 5, Open: 2, Dials: 3},
+			"r2": {Capacity: 5, Open: 1, Dials: 1},
+		},
+		Total: PoolStats{Capacity: 20, Open: 7, Dials: 10},
+	}
+
+	Expect(primary.Stats()).To(Equal(expected))
+	Expect(primary.ReadReplica().Stats()).To(Equal(expected))
+}
+
+func (s *ClientSuite) TestState(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		replicaPool = mocks.NewMockPool()
+		primary     = makeClient(primaryPool, nil)
+		readClient  = makeClient(replicaPool, nil)
+	)
+
+	primary.role, primary.addr = RolePrimary, "primary"
+	readClient.role, readClient.addr = RoleReplica, "r1"
+	primaryPool.StatsFunc.SetDefaultReturn(PoolStats{Capacity: 10, Open: 4})
+	replicaPool.StatsFunc.SetDefaultReturn(PoolStats{Capacity: 5, BreakerOpen: true})
+
+	primary.readReplicaClient = &replicaClient{
+		primary: primary,
+		replicas: []*replica{
+			{addr: "r1", client: readClient, failures: 3, staleness: time.Second, stalenessKnown: true},
+		},
+	}
+
+	expected := ClientState{
+		Primary: ServerState{
+			Role:    RolePrimary,
+			Addr:    "primary",
+			Healthy: true,
+			Stats:   PoolStats{Capacity: 10, Open: 4},
+			Leaks:   []LeakReport{},
+		},
+		Replicas: []ServerState{
+			{
+				Role:           RoleReplica,
+				Addr:           "r1",
+				Failures:       3,
+				Staleness:      time.Second,
+				StalenessKnown: true,
+				Stats:          PoolStats{Capacity: 5, BreakerOpen: true},
+				Leaks:          []LeakReport{},
+			},
+		},
+	}
+
+	Expect(primary.State()).To(Equal(expected))
+	Expect(primary.ReadReplica().State()).To(Equal(expected))
+}
+
 //
 // Helpers
 
+// Make the error-returning borrow methods of the mock pool defer to the
+// Borrow and BorrowTimeout mocks, so that tests only configure the latter.
+func borrowThroughMock(pool *mocks.MockPool) {
+	pool.BorrowErrFunc.SetDefaultHook(func() (Conn, error) {
+		return borrowErr(pool.Borrow())
+	})
+
+	pool.BorrowTimeoutErrFunc.SetDefaultHook(func(timeout time.Duration) (Conn, error) {
+		return borrowErr(pool.BorrowTimeout(timeout))
+	})
+}
+
+func borrowErr(conn Conn, ok bool) (Conn, error) {
+	if !ok {
+		return nil, ErrNoConnection
+	}
+
+	return conn, nil
+}
+
+// Count the borrowed connections which the pool is tracking.
+func trackedConns(p Pool) int {
+	p.(*pool).createdMutex.Lock()
+	defer p.(*pool).createdMutex.Unlock()
+
+	return len(p.(*pool).created) + len(p.(*pool).forced)
+}
+
 func makeClient(pool Pool, clock glock.Clock) *client {
+	if mock, ok := pool.(*mocks.MockPool); ok {
+		borrowThroughMock(mock)
+	}
+
+	if clock == nil {
+		clock = glock.NewRealClock()
+	}
+
 	return &client{
 		pool:    pool,
 		backoff: defaultBackoff,
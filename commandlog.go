@@ -0,0 +1,287 @@
+package deepjoy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/efritz/glock"
+)
+
+type (
+	// CommandLog receives entries describing commands run by a client. See
+	// WithSlowLog and WithAuditLog. Entries are recorded synchronously once
+	// a command completes, so implementations should not block.
+	CommandLog interface {
+		// Record adds an entry to the log.
+		Record(entry CommandLogEntry)
+	}
+
+	// CommandLogFunc is a function which implements CommandLog.
+	CommandLogFunc func(entry CommandLogEntry)
+
+	// CommandFilter determines if a command (given by its upper-case name)
+	// should be recorded to an audit log.
+	CommandFilter func(command string) bool
+
+	// CommandLogEntry describes a single command or pipeline.
+	CommandLogEntry struct {
+		// Time is the time at which the command was invoked.
+		Time time.Time
+
+		// Command is the upper-case name of the command, or PIPELINE.
+		Command string
+
+		// Key is the first argument of the command, unless it is redacted.
+		// The key of a pipeline is empty.
+		Key string
+
+		// Args are the redacted arguments of the command (see
+		// WithArgRedaction). For a pipeline, each element is a command
+		// followed by its redacted arguments.
+		Args []string
+
+		// Duration is the total time spent running the command, including
+		// borrowing connections and backing off between retries.
+		Duration time.Duration
+
+		// Retries is the number of times the command was retried.
+		Retries int
+
+		// PoolWait is the total time spent borrowing connections.
+		PoolWait time.Duration
+
+		// Err is the error returned from the command, if any.
+		Err error
+	}
+
+	// CommandLogBuffer is a CommandLog which keeps a fixed number of the
+	// most recent entries in memory.
+	CommandLogBuffer struct {
+		mutex   sync.Mutex
+		entries []CommandLogEntry
+		next    int
+		full    bool
+	}
+
+	commandLogger struct {
+		redactor      redactor
+		slowThreshold time.Duration
+		slowLog       CommandLog
+		auditFilter   CommandFilter
+		auditLog      CommandLog
+		clock         glock.Clock
+	}
+
+	// commandRecord accumulates the retries and pool wait time of a command
+	// across its attempts. A pointer to the record is stored in the context
+	// passed to each attempt.
+	commandRecord struct {
+		start   time.Time
+		retries int
+		wait    time.Duration
+	}
+
+	commandRecordKey struct{}
+)
+
+// writeCommands are the commands matched by WriteCommands.
+var writeCommands = map[string]struct{}{}
+
+func init() {
+	for _, command := range strings.Fields(`
+		APPEND BITFIELD BITOP BLMOVE BLPOP BRPOP BRPOPLPUSH BZPOPMAX BZPOPMIN
+		COPY DECR DECRBY DEL EVAL EVALSHA EXPIRE EXPIREAT FCALL FLUSHALL FLUSHDB
+		GEOADD GETDEL GETEX GETSET HDEL HINCRBY HINCRBYFLOAT HMSET HSET HSETNX
+		INCR INCRBY INCRBYFLOAT LINSERT LMOVE LPOP LPUSH LPUSHX LREM LSET LTRIM
+		MOVE MSET MSETNX PERSIST PEXPIRE PEXPIREAT PFADD PFMERGE PSETEX RENAME
+		RENAMENX RESTORE RPOP RPOPLPUSH RPUSH RPUSHX SADD SDIFFSTORE SET SETBIT
+		SETEX SETNX SETRANGE SINTERSTORE SMOVE SPOP SREM SUNIONSTORE SWAPDB
+		UNLINK XACK XADD XAUTOCLAIM XCLAIM XDEL XGROUP XTRIM ZADD ZDIFFSTORE
+		ZINCRBY ZINTERSTORE ZPOPMAX ZPOPMIN ZRANGESTORE ZREM ZREMRANGEBYLEX
+		ZREMRANGEBYRANK ZREMRANGEBYSCORE ZUNIONSTORE
+	`) {
+		writeCommands[command] = struct{}{}
+	}
+}
+
+// WriteCommands is a CommandFilter which matches commands that modify data.
+// Scripts (EVAL, EVALSHA, and FCALL) are treated as writes.
+func WriteCommands(command string) bool {
+	_, ok := writeCommands[command]
+	return ok
+}
+
+// Record invokes the function.
+func (f CommandLogFunc) Record(entry CommandLogEntry) {
+	f(entry)
+}
+
+// NewCommandLogBuffer creates a CommandLogBuffer which keeps the given
+// number of entries.
+func NewCommandLogBuffer(size int) *CommandLogBuffer {
+	if size < 1 {
+		size = 1
+	}
+
+	return &CommandLogBuffer{entries: make([]CommandLogEntry, size)}
+}
+
+// Record adds an entry to the buffer, replacing the oldest entry if the
+// buffer is full.
+func (b *CommandLogBuffer) Record(entry CommandLogEntry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	b.full = b.full || b.next == 0
+}
+
+// Entries returns the entries in the buffer from oldest to newest.
+func (b *CommandLogBuffer) Entries() []CommandLogEntry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.full {
+		return append([]CommandLogEntry(nil), b.entries[:b.next]...)
+	}
+
+	return append(append([]CommandLogEntry(nil), b.entries[b.next:]...), b.entries[:b.next]...)
+}
+
+// Create a command logger from the config. Returns nil if neither a slow
+// log nor an audit log is configured.
+func newCommandLogger(config *clientConfig) *commandLogger {
+	if config.slowLog == nil && config.auditLog == nil {
+		return nil
+	}
+
+	return &commandLogger{
+		redactor:      newRedactor(config.redactions),
+		slowThreshold: config.slowLogThreshold,
+		slowLog:       config.slowLog,
+		auditFilter:   config.auditFilter,
+		auditLog:      config.auditLog,
+		clock:         config.clock,
+	}
+}
+
+// Begin recording a command. The returned context carries the record to
+// each attempt of the command.
+func (l *commandLogger) start(ctx context.Context) (context.Context, *commandRecord) {
+	if l == nil {
+		return ctx, nil
+	}
+
+	record := &commandRecord{start: l.clock.Now()}
+	return context.WithValue(ctx, commandRecordKey{}, record), record
+}
+
+// Count a retry of the command recorded in the context.
+func (l *commandLogger) retried(ctx context.Context) {
+	if l == nil {
+		return
+	}
+
+	if record, ok := ctx.Value(commandRecordKey{}).(*commandRecord); ok {
+		record.retries++
+	}
+}
+
+// Add the time since the given start of a borrow to the pool wait time of
+// the command recorded in the context.
+func (l *commandLogger) waited(ctx context.Context, start time.Time) {
+	if l == nil {
+		return
+	}
+
+	if record, ok := ctx.Value(commandRecordKey{}).(*commandRecord); ok {
+		record.wait += l.clock.Since(start)
+	}
+}
+
+// Record a completed command to the configured logs.
+func (l *commandLogger) finish(record *commandRecord, command string, args []interface{}, err error) {
+	if l == nil {
+		return
+	}
+
+	var (
+		name  = strings.ToUpper(command)
+		slow  = l.isSlow(record)
+		audit = l.isAudited(name)
+	)
+
+	if !slow && !audit {
+		return
+	}
+
+	entry := l.entry(record, name, err)
+	entry.Args = l.redactor.redact(name, args)
+	if len(entry.Args) > 0 && entry.Args[0] != RedactedArg {
+		entry.Key = entry.Args[0]
+	}
+
+	l.record(entry, slow, audit)
+}
+
+// Record a completed pipeline to the configured logs.
+func (l *commandLogger) finishPipeline(record *commandRecord, commands []commandPair, err error) {
+	if l == nil {
+		return
+	}
+
+	var (
+		slow  = l.isSlow(record)
+		audit = false
+	)
+
+	for _, command := range commands {
+		if l.isAudited(strings.ToUpper(command.command)) {
+			audit = true
+			break
+		}
+	}
+
+	if !slow && !audit {
+		return
+	}
+
+	entry := l.entry(record, pipelineCommandLabel, err)
+	for _, command := range commands {
+		entry.Args = append(entry.Args, l.redactor.statement(strings.ToUpper(command.command), command.args))
+	}
+
+	l.record(entry, slow, audit)
+}
+
+func (l *commandLogger) isSlow(record *commandRecord) bool {
+	return l.slowLog != nil && l.clock.Since(record.start) >= l.slowThreshold
+}
+
+func (l *commandLogger) isAudited(command string) bool {
+	return l.auditLog != nil && (l.auditFilter == nil || l.auditFilter(command))
+}
+
+func (l *commandLogger) entry(record *commandRecord, command string, err error) CommandLogEntry {
+	return CommandLogEntry{
+		Time:     record.start,
+		Command:  command,
+		Duration: l.clock.Since(record.start),
+		Retries:  record.retries,
+		PoolWait: record.wait,
+		Err:      err,
+	}
+}
+
+func (l *commandLogger) record(entry CommandLogEntry, slow, audit bool) {
+	if slow {
+		l.slowLog.Record(entry)
+	}
+
+	if audit {
+		l.auditLog.Record(entry)
+	}
+}
@@ -0,0 +1,202 @@
+package deepjoy
+
+import (
+	"io"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type CommandLogSuite struct{}
+
+func makeLoggedClient(pool Pool, clock glock.Clock, configs ...ConfigFunc) *client {
+	c := makeClient(pool, clock)
+
+	config := &clientConfig{clock: c.clock}
+	for _, f := range configs {
+		f(config)
+	}
+
+	c.commandLog = newCommandLogger(config)
+	return c
+}
+
+func (s *CommandLogSuite) TestSlowLog(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		clock  = glock.NewMockClock()
+		buffer = NewCommandLogBuffer(10)
+		c      = makeLoggedClient(pool, clock, WithSlowLog(0, buffer))
+	)
+
+	pool.BorrowFunc.SetDefaultHook(func() (Conn, bool) {
+		clock.Advance(time.Millisecond * 10)
+		return conn, true
+	})
+
+	conn.DoFunc.SetDefaultHook(func(command string, args ...interface{}) (interface{}, error) {
+		clock.Advance(time.Millisecond * 5)
+		return "OK", nil
+	})
+
+	_, err := c.Do("set", "foo", "bar")
+	Expect(err).To(BeNil())
+
+	entries := buffer.Entries()
+	Expect(entries).To(HaveLen(1))
+	Expect(entries[0].Command).To(Equal("SET"))
+	Expect(entries[0].Key).To(Equal("foo"))
+	Expect(entries[0].Args).To(Equal([]string{"foo", "?"}))
+	Expect(entries[0].Retries).To(Equal(0))
+	Expect(entries[0].PoolWait).To(Equal(time.Millisecond * 10))
+	Expect(entries[0].Duration).To(Equal(time.Millisecond * 15))
+	Expect(entries[0].Err).To(BeNil())
+}
+
+func (s *CommandLogSuite) TestSlowLogThreshold(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		buffer = NewCommandLogBuffer(10)
+		c      = makeLoggedClient(pool, nil, WithSlowLog(time.Minute, buffer))
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+	Expect(buffer.Entries()).To(BeEmpty())
+}
+
+func (s *CommandLogSuite) TestRetries(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn1  = mocks.NewMockConn()
+		conn2  = mocks.NewMockConn()
+		clock  = glock.NewMockClock()
+		buffer = NewCommandLogBuffer(10)
+		c      = makeLoggedClient(pool, clock, WithSlowLog(0, buffer))
+	)
+
+	pool.BorrowFunc.PushReturn(conn1, true)
+	pool.BorrowFunc.PushReturn(conn2, true)
+	conn1.DoFunc.SetDefaultReturn(nil, connErr{io.EOF})
+	conn2.DoFunc.SetDefaultReturn("bar", nil)
+
+	go func() {
+		// Unlock the after call in client
+		clock.BlockingAdvance(time.Second)
+	}()
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+
+	entries := buffer.Entries()
+	Expect(entries).To(HaveLen(1))
+	Expect(entries[0].Retries).To(Equal(1))
+}
+
+func (s *CommandLogSuite) TestAuditLog(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		buffer = NewCommandLogBuffer(10)
+		c      = makeLoggedClient(pool, nil, WithAuditLog(WriteCommands, buffer))
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.PushReturn("bar", nil)
+	conn.DoFunc.PushReturn(nil, ErrNoConnection)
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+	_, err = c.Do("DEL", "foo")
+	Expect(err).To(Equal(ErrNoConnection))
+
+	entries := buffer.Entries()
+	Expect(entries).To(HaveLen(1))
+	Expect(entries[0].Command).To(Equal("DEL"))
+	Expect(entries[0].Key).To(Equal("foo"))
+	Expect(entries[0].Err).To(Equal(ErrNoConnection))
+}
+
+func (s *CommandLogSuite) TestAuditLogRedaction(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		buffer = NewCommandLogBuffer(10)
+		c      = makeLoggedClient(pool, nil, WithAuditLog(nil, buffer), WithArgRedaction("SET", KeepArgs(2)))
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("AUTH", "secret")
+	Expect(err).To(BeNil())
+	_, err = c.Do("SET", "foo", "bar", "EX", 10)
+	Expect(err).To(BeNil())
+
+	entries := buffer.Entries()
+	Expect(entries).To(HaveLen(2))
+	Expect(entries[0].Key).To(BeEmpty())
+	Expect(entries[0].Args).To(Equal([]string{"?"}))
+	Expect(entries[1].Args).To(Equal([]string{"foo", "bar", "?", "?"}))
+}
+
+func (s *CommandLogSuite) TestAuditLogPipeline(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		buffer = NewCommandLogBuffer(10)
+		c      = makeLoggedClient(pool, nil, WithAuditLog(WriteCommands, buffer))
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn([]interface{}{"OK", "bar"}, nil)
+
+	pipeline := c.Pipeline()
+	pipeline.Add("SET", "foo", "bar")
+	pipeline.Add("GET", "foo")
+	_, err := pipeline.Run()
+	Expect(err).To(BeNil())
+
+	entries := buffer.Entries()
+	Expect(entries).To(HaveLen(1))
+	Expect(entries[0].Command).To(Equal("PIPELINE"))
+	Expect(entries[0].Key).To(BeEmpty())
+	Expect(entries[0].Args).To(Equal([]string{"SET foo ?", "GET foo"}))
+}
+
+func (s *CommandLogSuite) TestBuffer(t sweet.T) {
+	buffer := NewCommandLogBuffer(3)
+	Expect(buffer.Entries()).To(BeEmpty())
+
+	for _, command := range []string{"A", "B"} {
+		buffer.Record(CommandLogEntry{Command: command})
+	}
+
+	Expect(commandNames(buffer.Entries())).To(Equal([]string{"A", "B"}))
+
+	for _, command := range []string{"C", "D", "E"} {
+		buffer.Record(CommandLogEntry{Command: command})
+	}
+
+	Expect(commandNames(buffer.Entries())).To(Equal([]string{"C", "D", "E"}))
+}
+
+func (s *CommandLogSuite) TestNoCommandLog(t sweet.T) {
+	Expect(newCommandLogger(&clientConfig{})).To(BeNil())
+}
+
+func commandNames(entries []CommandLogEntry) []string {
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Command)
+	}
+
+	return names
+}
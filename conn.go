@@ -1,7 +1,13 @@
 package deepjoy
 
 import (
+	"context"
+	"crypto/tls"
 	"math/rand"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 
@@ -18,36 +24,271 @@ type (
 	// DialerFactory creates a DialFunc for the given address.
 	DialerFactory func(addrs []string) DialFunc
 
+	// OnConnectFunc initializes a newly dialed connection before it is
+	// handed to a borrower. The given context expires after the connect
+	// timeout.
+	OnConnectFunc func(ctx context.Context, conn Conn) error
+
+	// ConnMiddleware wraps a connection in order to intercept the commands
+	// sent over it. The returned connection should delegate to the given
+	// connection.
+	ConnMiddleware func(next Conn) Conn
+
+	// NetDialFunc creates the network connection underlying a connection
+	// made by the default dialer. The given context expires after the
+	// connect timeout.
+	NetDialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
 	redigoShim struct {
-		conn redis.Conn
+		conn   redis.Conn
+		reauth bool
 	}
 
 	connErr struct{ error }
+
+	// reauthErr wraps an authentication error received by a connection
+	// which was authenticated with a credentials provider. The command is
+	// retried once on a connection dialed with fresh credentials.
+	reauthErr struct{ error }
+
+	// dialHealth records whether the most recent dial of a client failed.
+	dialHealth struct {
+		failing int32
+	}
 )
 
-func makeDefaultDialerFactory(config *clientConfig) DialerFactory {
+func makeDefaultDialerFactory(config *clientConfig, credentials CredentialsProvider) DialerFactory {
+	var loader *tlsLoader
+	if config.useTLS {
+		loader = newTLSLoader(config)
+	}
+
 	return func(addrs []string) DialFunc {
 		return func() (Conn, error) {
 			addr := chooseRandom(addrs)
 
-			config.logger.Printf("Attempting to dial redis at %s", addr)
+			config.logger.Debug("Attempting to dial redis", "addr", addr)
 
-			conn, err := redis.Dial(
-				"tcp",
-				addr,
-				redis.DialPassword(config.password),
-				redis.DialDatabase(config.database),
+			options := []redis.DialOption{
 				redis.DialConnectTimeout(config.connectTimeout),
 				redis.DialReadTimeout(config.readTimeout),
 				redis.DialWriteTimeout(config.writeTimeout),
+			}
+
+			var dial func(network, addr string) (net.Conn, error)
+			if config.netDialer != nil {
+				dial = makeNetDial(config.netDialer, config)
+			}
+
+			network, addr := parseTarget(addr)
+
+			if loader != nil {
+				tlsConfig, err := loader.config()
+				if err != nil {
+					return nil, err
+				}
+
+				dial = makeTLSDial(dial, tlsConfig, config)
+			}
+
+			if dial != nil {
+				options = append(options, redis.DialNetDial(dial))
+			}
+
+			if credentials != nil {
+				return dialWithCredentials(network, addr, options, credentials, config)
+			}
+
+			options = append(
+				options,
+				redis.DialPassword(config.password),
+				redis.DialDatabase(config.database),
 			)
 
+			conn, err := redis.Dial(network, addr, options...)
+			if err != nil {
+				return nil, err
+			}
+
+			return &redigoShim{conn: conn}, nil
+		}
+	}
+}
+
+// Dial a connection and authenticate it with the credentials returned from
+// the given provider. If the credentials are rejected, the provider is asked
+// for new credentials and the connection is dialed a second time.
+func dialWithCredentials(
+	network string,
+	addr string,
+	options []redis.DialOption,
+	credentials CredentialsProvider,
+	config *clientConfig,
+) (Conn, error) {
+	for attempt := 0; ; attempt++ {
+		conn, err := redis.Dial(network, addr, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		err = authenticate(conn, credentials, config)
+		if err == nil {
+			return &redigoShim{conn: conn, reauth: true}, nil
+		}
+
+		conn.Close()
+
+		if attempt > 0 || !isAuthError(err) {
+			return nil, err
+		}
+
+		config.logger.Warn("Credentials were rejected, refreshing credentials", "error", err)
+	}
+}
+
+// Wrap the dial function so that each new connection is initialized by the
+// given hooks, in order. If a hook fails, the connection is closed and the
+// dial fails.
+func makeInitializingDialer(dialer DialFunc, hooks []OnConnectFunc, config *clientConfig) DialFunc {
+	if len(hooks) == 0 {
+		return dialer
+	}
+
+	return func() (Conn, error) {
+		conn, err := dialer()
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.connectTimeout)
+		defer cancel()
+
+		for _, hook := range hooks {
+			if err := hook(ctx, conn); err != nil {
+				if err := conn.Close(); err != nil {
+					config.logger.Warn("Could not close connection", "error", err)
+				}
+
+				return nil, err
+			}
+		}
+
+		return conn, nil
+	}
+}
+
+// Wrap the dialer so that the outcome of each dial is recorded.
+func (h *dialHealth) wrap(dialer DialFunc) DialFunc {
+	return func() (Conn, error) {
+		conn, err := dialer()
+		if err != nil {
+			atomic.StoreInt32(&h.failing, 1)
+			return nil, err
+		}
+
+		atomic.StoreInt32(&h.failing, 0)
+		return conn, nil
+	}
+}
+
+// Determine if the most recent dial failed. A borrow fails with
+// ErrNoConnection both when the server cannot be reached and when the
+// pool is exhausted by a busy server; only the former is a failure.
+func (h *dialHealth) unreachable() bool {
+	return h != nil && atomic.LoadInt32(&h.failing) == 1
+}
+
+// Wrap each connection returned by the dialer in the given middleware. The
+// first middleware is the outermost, and so sees each command first.
+func makeMiddlewareDialer(dialer DialFunc, middleware []ConnMiddleware) DialFunc {
+	if len(middleware) == 0 {
+		return dialer
+	}
+
+	return func() (Conn, error) {
+		conn, err := dialer()
+		if err != nil {
+			return nil, err
+		}
+
+		for i := len(middleware) - 1; i >= 0; i-- {
+			conn = middleware[i](conn)
+		}
+
+		return conn, nil
+	}
+}
+
+// Split a target of the form network:address into its network and address.
+// Targets without a known network prefix (e.g. host:port) use TCP.
+func parseTarget(target string) (string, string) {
+	if index := strings.Index(target, ":"); index >= 0 {
+		switch network := target[:index]; network {
+		case "tcp", "tcp4", "tcp6", "unix":
+			return network, target[index+1:]
+		}
+	}
+
+	return "tcp", target
+}
+
+// Adapt a context-aware net dial function to the signature expected
+// by redigo, applying the connect timeout to the context.
+func makeNetDial(dialer NetDialFunc, config *clientConfig) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.connectTimeout)
+		defer cancel()
+
+		return dialer(ctx, network, addr)
+	}
+}
+
+// Wrap the dialer so that each connection is upgraded to TLS. The handshake
+// is bounded by the connect timeout, so that a server which accepts the
+// connection but never responds cannot block the dial indefinitely. A nil
+// dialer dials with the connect timeout.
+func makeTLSDial(dial func(network, addr string) (net.Conn, error), tlsConfig *tls.Config, config *clientConfig) func(network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: config.connectTimeout, KeepAlive: time.Minute * 5}).Dial
+	}
+
+	return func(network, addr string) (net.Conn, error) {
+		conn, err := dial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if tlsConfig.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
 			if err != nil {
+				conn.Close()
 				return nil, err
 			}
 
-			return &redigoShim{conn}, nil
+			tlsConfig.ServerName = host
 		}
+
+		if config.connectTimeout > 0 {
+			if err := conn.SetDeadline(time.Now().Add(config.connectTimeout)); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		// Clear the handshake deadline (read and write timeouts are set
+		// per command by the connection)
+		if err := conn.SetDeadline(time.Time{}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return tlsConn, nil
 	}
 }
 
@@ -65,14 +306,14 @@ func (s *redigoShim) Close() error {
 
 func (s *redigoShim) Do(command string, args ...interface{}) (interface{}, error) {
 	result, err := s.conn.Do(command, args...)
-	return result, s.wrapError(err)
+	return result, s.wrapError(command, err)
 }
 
 func (s *redigoShim) Send(command string, args ...interface{}) error {
-	return s.wrapError(s.conn.Send(command, args...))
+	return s.wrapError(command, s.conn.Send(command, args...))
 }
 
-func (s *redigoShim) wrapError(err error) error {
+func (s *redigoShim) wrapError(command string, err error) error {
 	// If there's an error on the connection, wrap it and return that
 	// so we can flag the retry loop in the client to retry instead of
 	// returning the error on this attempt.
@@ -81,5 +322,14 @@ func (s *redigoShim) wrapError(err error) error {
 		return connErr{s.conn.Err()}
 	}
 
+	// If the connection was authenticated with a credentials provider
+	// and the credentials have since been revoked, the connection must
+	// be redialed with fresh credentials. Rejections of credentials sent
+	// by the caller are returned as-is.
+
+	if s.reauth && isAuthError(err) && !isAuthCommand(command) {
+		return reauthErr{err}
+	}
+
 	return err
 }
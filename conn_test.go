@@ -0,0 +1,122 @@
+package deepjoy
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aphistic/sweet"
+	. "github.com/onsi/gomega"
+)
+
+type DialerSuite struct{}
+
+func (s *DialerSuite) TestParseTarget(t sweet.T) {
+	for target, expected := range map[string][]string{
+		"dart.it.corp:6379":        {"tcp", "dart.it.corp:6379"},
+		"tcp:dart.it.corp:6379":    {"tcp", "dart.it.corp:6379"},
+		"tcp6:[::1]:6379":          {"tcp6", "[::1]:6379"},
+		"unix:/var/run/redis.sock": {"unix", "/var/run/redis.sock"},
+		"[::1]:6379":               {"tcp", "[::1]:6379"},
+	} {
+		network, addr := parseTarget(target)
+		Expect([]string{network, addr}).To(Equal(expected))
+	}
+}
+
+func (s *DialerSuite) TestUnixSocket(t sweet.T) {
+	dir := makeTempDir()
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "redis.sock")
+	listener, err := net.Listen("unix", path)
+	Expect(err).To(BeNil())
+
+	server := newRESPServer(listener, func(conn net.Conn, args []string) string {
+		return respSimple("PONG")
+	})
+
+	defer server.Close()
+
+	client := NewClient("unix:" + path)
+	defer client.Close()
+
+	Expect(client.Do("PING")).To(Equal("PONG"))
+}
+
+func (s *DialerSuite) TestNetDialer(t sweet.T) {
+	listener := newPipeListener()
+	server := newRESPServer(listener, func(conn net.Conn, args []string) string {
+		return respBulk(args[1])
+	})
+
+	defer server.Close()
+
+	var (
+		networks  = []string{}
+		addrs     = []string{}
+		deadlines = []bool{}
+	)
+
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, ok := ctx.Deadline()
+		networks = append(networks, network)
+		addrs = append(addrs, addr)
+		deadlines = append(deadlines, ok)
+		return listener.Dial(ctx, network, addr)
+	}
+
+	client := NewClient(
+		"dart.it.corp:6379",
+		WithNetDialer(dialer),
+		WithConnectTimeout(time.Second),
+	)
+
+	defer client.Close()
+
+	Expect(client.Do("ECHO", "foo")).To(Equal([]byte("foo")))
+	Expect(networks).To(Equal([]string{"tcp"}))
+	Expect(addrs).To(Equal([]string{"dart.it.corp:6379"}))
+	Expect(deadlines).To(Equal([]bool{true}))
+}
+
+func (s *DialerSuite) TestNetDialerTarget(t sweet.T) {
+	var networks, addrs []string
+
+	dial := newConfig([]ConfigFunc{
+		WithNetDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			networks = append(networks, network)
+			addrs = append(addrs, addr)
+			return nil, &net.OpError{Op: "dial", Net: network, Err: os.ErrNotExist}
+		}),
+	}).dialerFactory([]string{"unix:/var/run/redis.sock"})
+
+	_, err := dial()
+	Expect(err).NotTo(BeNil())
+	Expect(networks).To(Equal([]string{"unix"}))
+	Expect(addrs).To(Equal([]string{"/var/run/redis.sock"}))
+}
+
+func (s *DialerSuite) TestDialHealth(t sweet.T) {
+	var (
+		health  = &dialHealth{}
+		results = []error{nil, connErr{net.ErrClosed}, nil}
+		dialer  = health.wrap(func() (Conn, error) {
+			err := results[0]
+			results = results[1:]
+			return nil, err
+		})
+	)
+
+	Expect(health.unreachable()).To(BeFalse())
+	dialer()
+	Expect(health.unreachable()).To(BeFalse())
+	dialer()
+	Expect(health.unreachable()).To(BeTrue())
+	dialer()
+	Expect(health.unreachable()).To(BeFalse())
+
+	Expect((*dialHealth)(nil).unreachable()).To(BeFalse())
+}
@@ -0,0 +1,121 @@
+package deepjoy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/efritz/deepjoy/iface"
+)
+
+type (
+	// ConsistencyToken identifies a position in the replication stream
+	// of the primary.
+	ConsistencyToken = iface.ConsistencyToken
+
+	tokenErr struct{ error }
+)
+
+func (c *client) DoWithToken(command string, args ...interface{}) (interface{}, ConsistencyToken, error) {
+	token := ConsistencyToken{}
+	ctx, span := c.tracing.startCommand(context.Background(), command, args)
+	ctx, record := c.commandLog.start(ctx)
+
+	result, err := c.withRetry(ctx, func(ctx context.Context) (interface{}, error) {
+		result, t, err := c.doWithToken(ctx, command, args)
+		token = t
+		return result, err
+	})
+
+	c.commandLog.finish(record, command, args, err)
+	endSpan(span, err)
+
+	if err, ok := err.(tokenErr); ok {
+		return result, token, err.error
+	}
+
+	return result, token, err
+}
+
+func (c *client) ReadReplicaAfter(token ConsistencyToken) Client {
+	if c.readReplicaClient != nil {
+		return c.readReplicaClient.ReadReplicaAfter(token)
+	}
+
+	return c
+}
+
+func (c *replicaClient) DoWithToken(command string, args ...interface{}) (interface{}, ConsistencyToken, error) {
+	return c.primary.DoWithToken(command, args...)
+}
+
+// Invoke a command and determine the replication offset of the primary on
+// the same connection before releasing the connection back to the pool. If
+// the command succeeds but the offset cannot be determined, the result of
+// the command is returned along with a tokenErr so that the (possibly
+// non-idempotent) command is not retried.
+func (c *client) doWithToken(ctx context.Context, command string, args []interface{}) (interface{}, ConsistencyToken, error) {
+	if err := checkCommand(command, args); err != nil {
+		return nil, ConsistencyToken{}, err
+	}
+
+	var token ConsistencyToken
+
+	result, err := c.hooks.do(ctx, c.clock, command, args, func(ctx context.Context) (interface{}, error) {
+		conn, err := c.timedBorrow(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := conn.Do(command, args...)
+		if err != nil {
+			c.release(conn, err)
+			return nil, err
+		}
+
+		token, err = c.token(conn)
+		if err != nil {
+			c.release(conn, err)
+			return result, tokenErr{fmt.Errorf("could not determine replication offset (%s)", err.Error())}
+		}
+
+		reset := sessionReset{}
+		reset.add(command, args)
+		c.release(conn, c.resetSession(conn, reset))
+		return result, nil
+	})
+
+	return result, token, err
+}
+
+// Create a consistency token from the current replication offset of the
+// primary. If the client is configured to wait for replicas, a WAIT command
+// is issued first so that the token records how many replicas have already
+// acknowledged the writes made on this connection.
+func (c *client) token(conn Conn) (ConsistencyToken, error) {
+	token := ConsistencyToken{}
+
+	if c.waitReplicas > 0 {
+		replicas, err := conn.Do("WAIT", c.waitReplicas, int64(c.waitTimeout/time.Millisecond))
+		if err != nil {
+			return token, err
+		}
+
+		if n, ok := replicas.(int64); ok {
+			token.Replicas = int(n)
+		}
+	}
+
+	reply, err := conn.Do("INFO", "replication")
+	if err != nil {
+		return token, err
+	}
+
+	info, err := parseReplicationInfo(reply)
+	if err != nil {
+		return token, err
+	}
+
+	token.Offset = info.offset
+	return token, nil
+}
@@ -0,0 +1,67 @@
+package deepjoy
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// CredentialsProvider returns the username and password used to authenticate
+// a new connection. The provider is invoked each time a connection is dialed,
+// so credentials can be rotated without recreating the client. An empty
+// username authenticates with the legacy single-password form of AUTH. The
+// given context expires after the connect timeout.
+type CredentialsProvider func(ctx context.Context) (username, password string, err error)
+
+// Create a credentials provider which always returns the given values.
+func staticCredentialsProvider(username, password string) CredentialsProvider {
+	return func(ctx context.Context) (string, string, error) {
+		return username, password, nil
+	}
+}
+
+// Authenticate the connection with the credentials returned from the given
+// provider and select the configured database.
+func authenticate(conn Conn, credentials CredentialsProvider, config *clientConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.connectTimeout)
+	defer cancel()
+
+	username, password, err := credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	if username != "" {
+		if _, err := conn.Do("AUTH", username, password); err != nil {
+			return err
+		}
+	} else if password != "" {
+		if _, err := conn.Do("AUTH", password); err != nil {
+			return err
+		}
+	}
+
+	if config.database != 0 {
+		if _, err := conn.Do("SELECT", config.database); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Determine if the error indicates that the connection's credentials were
+// rejected or are no longer valid.
+func isAuthError(err error) bool {
+	if err, ok := err.(redis.Error); ok {
+		return strings.HasPrefix(string(err), "WRONGPASS") || strings.HasPrefix(string(err), "NOAUTH")
+	}
+
+	return false
+}
+
+// Determine if the command authenticates the connection.
+func isAuthCommand(command string) bool {
+	return strings.EqualFold(command, "AUTH") || strings.EqualFold(command, "HELLO")
+}
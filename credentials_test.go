@@ -0,0 +1,272 @@
+package deepjoy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/aphistic/sweet"
+	. "github.com/onsi/gomega"
+)
+
+type (
+	CredentialsSuite struct{}
+
+	// authServer is a RESP server which accepts a single username and
+	// password pair and replies to authenticated commands with the name
+	// of the user that authenticated the connection.
+	authServer struct {
+		*respServer
+		username string
+		password string
+		commands []string
+		users    map[net.Conn]string
+		revoked  bool
+		mutex    sync.Mutex
+	}
+)
+
+func (s *CredentialsSuite) TestAuthenticate(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	client := NewClient(
+		server.Addr(),
+		WithDatabase(3),
+		WithCredentialsProvider(staticCredentialsProvider("app", "secret")),
+	)
+
+	defer client.Close()
+	Expect(client.Do("PING")).To(Equal("app"))
+	Expect(server.getCommands()).To(Equal([]string{"AUTH app secret", "SELECT 3", "PING"}))
+}
+
+func (s *CredentialsSuite) TestAuthenticatePasswordOnly(t sweet.T) {
+	server := newAuthServer("default", "secret")
+	defer server.Close()
+
+	client := NewClient(server.Addr(), WithCredentialsProvider(staticCredentialsProvider("", "secret")))
+	defer client.Close()
+
+	Expect(client.Do("PING")).To(Equal("default"))
+	Expect(server.getCommands()).To(Equal([]string{"AUTH secret", "PING"}))
+}
+
+func (s *CredentialsSuite) TestProviderCalledOnEachDial(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	var (
+		calls    = 0
+		provider = func(ctx context.Context) (string, string, error) {
+			calls++
+			return "app", "secret", nil
+		}
+	)
+
+	dial := newConfig([]ConfigFunc{WithCredentialsProvider(provider)}).dialerFactory([]string{server.Addr()})
+
+	for i := 0; i < 3; i++ {
+		conn, err := dial()
+		Expect(err).To(BeNil())
+		conn.Close()
+	}
+
+	Expect(calls).To(Equal(3))
+}
+
+func (s *CredentialsSuite) TestRefreshOnWrongPass(t sweet.T) {
+	server := newAuthServer("app", "rotated")
+	defer server.Close()
+
+	var (
+		passwords = []string{"expired", "rotated"}
+		provider  = func(ctx context.Context) (string, string, error) {
+			password := passwords[0]
+			passwords = passwords[1:]
+			return "app", password, nil
+		}
+	)
+
+	client := NewClient(server.Addr(), WithCredentialsProvider(provider))
+	defer client.Close()
+
+	Expect(client.Do("PING")).To(Equal("app"))
+	Expect(passwords).To(BeEmpty())
+}
+
+func (s *CredentialsSuite) TestRefreshFailure(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	dial := newConfig([]ConfigFunc{
+		WithCredentialsProvider(staticCredentialsProvider("app", "expired")),
+	}).dialerFactory([]string{server.Addr()})
+
+	_, err := dial()
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(HavePrefix("WRONGPASS"))
+	Expect(server.getCommands()).To(Equal([]string{"AUTH app expired", "AUTH app expired"}))
+}
+
+func (s *CredentialsSuite) TestProviderError(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	dial := newConfig([]ConfigFunc{
+		WithCredentialsProvider(func(ctx context.Context) (string, string, error) {
+			return "", "", fmt.Errorf("utoh")
+		}),
+	}).dialerFactory([]string{server.Addr()})
+
+	_, err := dial()
+	Expect(err).To(MatchError("utoh"))
+	Expect(server.getCommands()).To(BeEmpty())
+}
+
+func (s *CredentialsSuite) TestRedialOnRevokedCredentials(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	var (
+		password = "secret"
+		provider = func(ctx context.Context) (string, string, error) {
+			return "app", password, nil
+		}
+	)
+
+	client := NewClient(server.Addr(), WithCredentialsProvider(provider))
+	defer client.Close()
+
+	Expect(client.Do("PING")).To(Equal("app"))
+
+	// Rotate credentials and revoke existing sessions
+	password = "rotated"
+	server.setPassword("rotated")
+
+	Expect(client.Do("PING")).To(Equal("app"))
+}
+
+func (s *CredentialsSuite) TestRedialOnce(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	client := NewClient(server.Addr(), WithCredentialsProvider(staticCredentialsProvider("app", "secret")))
+	defer client.Close()
+
+	Expect(client.Do("PING")).To(Equal("app"))
+
+	// Reject commands on every connection, including those with fresh credentials
+	server.revoke()
+
+	_, err := client.Do("PING")
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(HavePrefix("NOAUTH"))
+	Expect(server.getCommands()).To(Equal([]string{"AUTH app secret", "PING", "PING", "AUTH app secret", "PING"}))
+}
+
+func (s *CredentialsSuite) TestCallerAuthNotRetried(t sweet.T) {
+	server := newAuthServer("app", "secret")
+	defer server.Close()
+
+	client := NewClient(server.Addr(), WithCredentialsProvider(staticCredentialsProvider("app", "secret")))
+	defer client.Close()
+
+	_, err := client.Do("AUTH", "app", "wrong")
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(HavePrefix("WRONGPASS"))
+	Expect(server.getCommands()).To(Equal([]string{"AUTH app secret", "AUTH app wrong"}))
+}
+
+func (s *CredentialsSuite) TestReplicaCredentials(t sweet.T) {
+	primary := newAuthServer("writer", "secret")
+	defer primary.Close()
+
+	replica := newAuthServer("reader", "secret")
+	defer replica.Close()
+
+	client := NewClient(
+		primary.Addr(),
+		WithReadReplicaAddrs(replica.Addr()),
+		WithReplicaHealthCheckInterval(0),
+		WithCredentialsProvider(staticCredentialsProvider("writer", "secret")),
+		WithReplicaCredentialsProvider(staticCredentialsProvider("reader", "secret")),
+	)
+
+	defer client.Close()
+	Expect(client.Do("PING")).To(Equal("writer"))
+	Expect(client.ReadReplica().Do("PING")).To(Equal("reader"))
+}
+
+//
+// Helpers
+
+func newAuthServer(username, password string) *authServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+
+	s := &authServer{
+		username: username,
+		password: password,
+		users:    map[net.Conn]string{},
+	}
+
+	s.respServer = newRESPServer(listener, s.handle)
+	return s
+}
+
+func (s *authServer) handle(conn net.Conn, args []string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.commands = append(s.commands, strings.Join(args, " "))
+
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		username := "default"
+		if len(args) == 3 {
+			username = args[1]
+		}
+
+		if username != s.username || args[len(args)-1] != s.password {
+			return respError("WRONGPASS invalid username-password pair or user is disabled.")
+		}
+
+		s.users[conn] = username
+		return respSimple("OK")
+
+	case "SELECT":
+		return respSimple("OK")
+	}
+
+	username, ok := s.users[conn]
+	if !ok || s.revoked {
+		return respError("NOAUTH Authentication required.")
+	}
+
+	return respSimple(username)
+}
+
+func (s *authServer) setPassword(password string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.password = password
+	s.users = map[net.Conn]string{}
+}
+
+func (s *authServer) revoke() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.revoked = true
+}
+
+func (s *authServer) getCommands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
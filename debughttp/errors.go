@@ -0,0 +1,68 @@
+package debughttp
+
+import (
+	"sync"
+
+	"github.com/efritz/deepjoy"
+)
+
+// errorBuffer keeps the most recent events of a client which carry an
+// error.
+type errorBuffer struct {
+	mutex  sync.Mutex
+	events []deepjoy.Event
+	next   int
+	full   bool
+}
+
+func newErrorBuffer(size int) *errorBuffer {
+	if size < 1 {
+		size = 1
+	}
+
+	return &errorBuffer{events: make([]deepjoy.Event, size)}
+}
+
+// Record each event with an error until the subscription is closed.
+func (b *errorBuffer) collect(subscription deepjoy.EventSubscription) {
+	for event := range subscription.C() {
+		if event.Err != nil {
+			b.record(event)
+		}
+	}
+}
+
+func (b *errorBuffer) record(event deepjoy.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.events[b.next] = event
+	b.next = (b.next + 1) % len(b.events)
+	b.full = b.full || b.next == 0
+}
+
+// Return the recorded errors from newest to oldest.
+func (b *errorBuffer) entries() []errorEntry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	n := b.next
+	if b.full {
+		n = len(b.events)
+	}
+
+	entries := make([]errorEntry, 0, n)
+	for i := 1; i <= n; i++ {
+		event := b.events[(b.next-i+len(b.events))%len(b.events)]
+
+		entries = append(entries, errorEntry{
+			Time:  event.Time,
+			Event: event.Type.String(),
+			Role:  event.Role,
+			Addr:  event.Addr,
+			Error: event.Err.Error(),
+		})
+	}
+
+	return entries
+}
@@ -0,0 +1,195 @@
+// Package debughttp provides an HTTP handler which exposes the state of a
+// deepjoy client for debugging. Mount it under a prefix with StripPrefix:
+//
+//	mux.Handle("/debug/redis/", http.StripPrefix("/debug/redis", debughttp.Handler(client)))
+//
+// The root path renders the configured servers, their pool stats, breaker
+// and replica health, leaked connections, recent errors, and (optionally)
+// the slow log as HTML, or as JSON when requested with ?format=json or an
+// Accept header of application/json. The /healthz path runs a PING through
+// the client and responds with 503 if it fails.
+package debughttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/efritz/deepjoy"
+)
+
+type (
+	// StateHandler is an http.Handler which serves the state of a client.
+	StateHandler interface {
+		http.Handler
+
+		// Close releases the subscription to the events of the client.
+		// Recent errors are no longer collected once the handler is
+		// closed, but the handler can still serve requests.
+		Close()
+	}
+
+	// ConfigFunc is a function used to configure the handler.
+	ConfigFunc func(*handler)
+
+	handler struct {
+		client       deepjoy.Client
+		slowLog      *deepjoy.CommandLogBuffer
+		subscription deepjoy.EventSubscription
+		errors       *errorBuffer
+		errorsSize   int
+		pingTimeout  time.Duration
+		mux          *http.ServeMux
+	}
+)
+
+const (
+	defaultErrorsSize  = 50
+	defaultPingTimeout = time.Second
+)
+
+// Handler creates an http.Handler which serves the state of the given
+// client. Recent errors are collected from the events of the client from
+// the time the handler is created until the handler or the client is
+// closed. Each handler holds its own subscription, so a handler which is
+// replaced while the client is still in use should be closed.
+func Handler(client deepjoy.Client, configs ...ConfigFunc) StateHandler {
+	h := &handler{
+		client:      client,
+		errorsSize:  defaultErrorsSize,
+		pingTimeout: defaultPingTimeout,
+		mux:         http.NewServeMux(),
+	}
+
+	for _, f := range configs {
+		f(h)
+	}
+
+	h.subscription = client.Events()
+	h.errors = newErrorBuffer(h.errorsSize)
+	go h.errors.collect(h.subscription)
+
+	h.mux.HandleFunc("/healthz", h.serveHealth)
+	h.mux.HandleFunc("/", h.serveState)
+	return h
+}
+
+// WithSlowLog includes the entries of the given buffer in the rendered
+// state. This should be the buffer passed to deepjoy.WithSlowLog.
+func WithSlowLog(buffer *deepjoy.CommandLogBuffer) ConfigFunc {
+	return func(h *handler) { h.slowLog = buffer }
+}
+
+// WithRecentErrors sets the number of recent errors to keep. The default
+// is 50.
+func WithRecentErrors(n int) ConfigFunc {
+	return func(h *handler) { h.errorsSize = n }
+}
+
+// WithPingTimeout sets the maximum duration of the PING command run by the
+// health check. The default is one second.
+func WithPingTimeout(timeout time.Duration) ConfigFunc {
+	return func(h *handler) { h.pingTimeout = timeout }
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *handler) Close() {
+	h.subscription.Close()
+}
+
+// Respond with 200 if the client can PING the primary before the timeout
+// elapses, and 503 otherwise. The context given to the client bounds only
+// the borrow and any retries, so the response does not wait for a PING
+// which is still in flight (e.g. to a hung server) once the timeout elapses.
+func (h *handler) serveHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.pingTimeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := h.client.DoContext(ctx, "PING")
+		result <- err
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "unhealthy: %s\n", err)
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
+
+func (h *handler) serveState(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s := h.snapshot()
+	w.Header().Set("Cache-Control", "no-store")
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(s)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, s); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *handler) snapshot() snapshot {
+	state := h.client.State()
+
+	s := snapshot{
+		Time:         time.Now(),
+		Primary:      newServer(state.Primary),
+		Replicas:     []server{},
+		RecentErrors: h.errors.entries(),
+		SlowLog:      []command{},
+	}
+
+	for _, replica := range state.Replicas {
+		s.Replicas = append(s.Replicas, newServer(replica))
+	}
+
+	if h.slowLog != nil {
+		entries := h.slowLog.Entries()
+
+		// Render the most recent entries first
+		for i := len(entries) - 1; i >= 0; i-- {
+			s.SlowLog = append(s.SlowLog, newCommand(entries[i]))
+		}
+	}
+
+	return s
+}
+
+func wantsJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "json"
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
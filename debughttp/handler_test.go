@@ -0,0 +1,232 @@
+package debughttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/efritz/deepjoy"
+	"github.com/efritz/deepjoy/mocks"
+	. "github.com/onsi/gomega"
+)
+
+func TestStateJSON(t *testing.T) {
+	var (
+		g       = NewGomegaWithT(t)
+		client  = makeClient()
+		events  = make(chan deepjoy.Event, 10)
+		slowLog = deepjoy.NewCommandLogBuffer(10)
+	)
+
+	client.EventsFunc.SetDefaultReturn(makeSubscription(events))
+	slowLog.Record(deepjoy.CommandLogEntry{Command: "GET", Key: "foo", Args: []string{"foo"}, Duration: time.Second, Retries: 1})
+	slowLog.Record(deepjoy.CommandLogEntry{Command: "DEL", Key: "bar", Args: []string{"bar"}, Err: errors.New("oops")})
+
+	h := Handler(client, WithSlowLog(slowLog)).(*handler)
+	events <- deepjoy.Event{Type: deepjoy.DialSucceeded, Role: deepjoy.RolePrimary, Addr: "primary"}
+	events <- deepjoy.Event{Type: deepjoy.DialFailed, Role: deepjoy.RoleReplica, Addr: "r1", Err: errors.New("refused")}
+	g.Eventually(h.errors.entries).Should(HaveLen(1))
+
+	request := httptest.NewRequest("GET", "/?format=json", nil)
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, request)
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+	g.Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+	s := snapshot{}
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &s)).To(BeNil())
+	g.Expect(s.Primary.Addr).To(Equal("primary"))
+	g.Expect(s.Primary.Pool.Open).To(Equal(4))
+	g.Expect(s.Primary.Pool.WaitMs).To(Equal(1500.0))
+	g.Expect(s.Primary.StalenessMs).To(BeNil())
+	g.Expect(s.Primary.LeakedConns).To(Equal([]leak{{HeldMs: 60000, Stack: "goroutine 1"}}))
+	g.Expect(s.Replicas).To(HaveLen(1))
+	g.Expect(s.Replicas[0].Healthy).To(BeFalse())
+	g.Expect(s.Replicas[0].BreakerOpen).To(BeTrue())
+	g.Expect(*s.Replicas[0].StalenessMs).To(Equal(250.0))
+
+	g.Expect(s.RecentErrors).To(HaveLen(1))
+	g.Expect(s.RecentErrors[0].Event).To(Equal("DialFailed"))
+	g.Expect(s.RecentErrors[0].Addr).To(Equal("r1"))
+	g.Expect(s.RecentErrors[0].Error).To(Equal("refused"))
+
+	g.Expect(s.SlowLog).To(HaveLen(2))
+	g.Expect(s.SlowLog[0].Command).To(Equal("DEL"))
+	g.Expect(s.SlowLog[0].Error).To(Equal("oops"))
+	g.Expect(s.SlowLog[1].Command).To(Equal("GET"))
+	g.Expect(s.SlowLog[1].DurationMs).To(Equal(1000.0))
+	g.Expect(s.SlowLog[1].Retries).To(Equal(1))
+}
+
+func TestStateHTML(t *testing.T) {
+	var (
+		g      = NewGomegaWithT(t)
+		client = makeClient()
+	)
+
+	h := Handler(client)
+	request := httptest.NewRequest("GET", "/", nil)
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, request)
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+	g.Expect(recorder.Header().Get("Content-Type")).To(ContainSubstring("text/html"))
+	g.Expect(recorder.Body.String()).To(SatisfyAll(
+		ContainSubstring("<td>primary</td>"),
+		ContainSubstring("<td>r1</td>"),
+		ContainSubstring(`<span class="bad">open</span>`),
+		ContainSubstring("250.0ms"),
+		ContainSubstring("&lt;script&gt;"),
+	))
+
+	request = httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Accept", "application/json")
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, request)
+	g.Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+}
+
+func TestHealth(t *testing.T) {
+	var (
+		g      = NewGomegaWithT(t)
+		client = makeClient()
+	)
+
+	client.DoContextFunc.SetDefaultHook(func(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		g.Expect(ok).To(BeTrue())
+		g.Expect(time.Until(deadline)).To(BeNumerically("<=", time.Millisecond*100))
+		g.Expect(command).To(Equal("PING"))
+		return "PONG", nil
+	})
+
+	h := Handler(client, WithPingTimeout(time.Millisecond*100))
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+	g.Expect(recorder.Body.String()).To(Equal("ok\n"))
+
+	client.DoContextFunc.SetDefaultReturn(nil, deepjoy.ErrNoConnection)
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(recorder.Body.String()).To(ContainSubstring(deepjoy.ErrNoConnection.Error()))
+}
+
+func TestHealthHungServer(t *testing.T) {
+	var (
+		g       = NewGomegaWithT(t)
+		client  = makeClient()
+		unblock = make(chan struct{})
+	)
+
+	defer close(unblock)
+
+	// The command does not observe the context once it is sent
+	client.DoContextFunc.SetDefaultHook(func(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+		<-unblock
+		return "PONG", nil
+	})
+
+	h := Handler(client, WithPingTimeout(time.Millisecond*50))
+	recorder := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	g.Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(recorder.Body.String()).To(ContainSubstring(context.DeadlineExceeded.Error()))
+}
+
+func TestNotFound(t *testing.T) {
+	var (
+		g        = NewGomegaWithT(t)
+		recorder = httptest.NewRecorder()
+	)
+
+	Handler(makeClient()).ServeHTTP(recorder, httptest.NewRequest("GET", "/missing", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusNotFound))
+}
+
+func TestClose(t *testing.T) {
+	var (
+		g            = NewGomegaWithT(t)
+		client       = makeClient()
+		events       = make(chan deepjoy.Event, 10)
+		subscription = makeSubscription(events)
+	)
+
+	subscription.CloseFunc.SetDefaultHook(func() { close(events) })
+	client.EventsFunc.SetDefaultReturn(subscription)
+
+	h := Handler(client)
+	h.Close()
+	g.Expect(subscription.CloseFunc.History()).To(HaveLen(1))
+
+	// The handler still serves requests after it is closed
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+}
+
+func TestErrorBuffer(t *testing.T) {
+	var (
+		g      = NewGomegaWithT(t)
+		buffer = newErrorBuffer(2)
+	)
+
+	g.Expect(buffer.entries()).To(BeEmpty())
+
+	for _, message := range []string{"a", "b", "c"} {
+		buffer.record(deepjoy.Event{Type: deepjoy.DialFailed, Err: errors.New(message)})
+	}
+
+	entries := buffer.entries()
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries[0].Error).To(Equal("c"))
+	g.Expect(entries[1].Error).To(Equal("b"))
+}
+
+// makeClient creates a mock client with a primary and a single replica
+// whose subscription to events is already closed.
+func makeClient() *mocks.MockClient {
+	client := mocks.NewMockClient()
+	client.EventsFunc.SetDefaultReturn(makeSubscription(nil))
+	client.StateFunc.SetDefaultReturn(deepjoy.ClientState{
+		Primary: deepjoy.ServerState{
+			Role:    deepjoy.RolePrimary,
+			Addr:    "primary",
+			Healthy: true,
+			Stats:   deepjoy.PoolStats{Capacity: 10, Open: 4, WaitDuration: time.Millisecond * 1500},
+			Leaks:   []deepjoy.LeakReport{{Held: time.Minute, Stack: "goroutine 1"}},
+		},
+		Replicas: []deepjoy.ServerState{
+			{
+				Role:           deepjoy.RoleReplica,
+				Addr:           "r1",
+				Failures:       3,
+				Staleness:      time.Millisecond * 250,
+				StalenessKnown: true,
+				Stats:          deepjoy.PoolStats{Capacity: 5, BreakerOpen: true},
+				Leaks:          []deepjoy.LeakReport{{Held: time.Minute, Stack: "<script>"}},
+			},
+		},
+	})
+
+	return client
+}
+
+// makeSubscription creates a subscription which delivers the events sent
+// on the given channel. If the channel is nil, the subscription is closed.
+func makeSubscription(events chan deepjoy.Event) *mocks.MockEventSubscription {
+	if events == nil {
+		events = make(chan deepjoy.Event)
+		close(events)
+	}
+
+	subscription := mocks.NewMockEventSubscription()
+	subscription.CFunc.SetDefaultReturn(events)
+	return subscription
+}
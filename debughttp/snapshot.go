@@ -0,0 +1,127 @@
+package debughttp
+
+import (
+	"time"
+
+	"github.com/efritz/deepjoy"
+)
+
+type (
+	// snapshot is the rendered state of a client. Durations are rendered
+	// in milliseconds.
+	snapshot struct {
+		Time         time.Time    `json:"time"`
+		Primary      server       `json:"primary"`
+		Replicas     []server     `json:"replicas"`
+		RecentErrors []errorEntry `json:"recent_errors"`
+		SlowLog      []command    `json:"slow_log"`
+	}
+
+	server struct {
+		Role        string    `json:"role"`
+		Addr        string    `json:"addr"`
+		Healthy     bool      `json:"healthy"`
+		Failures    int       `json:"failures"`
+		StalenessMs *float64  `json:"staleness_ms,omitempty"`
+		BreakerOpen bool      `json:"breaker_open"`
+		Pool        poolStats `json:"pool"`
+		LeakedConns []leak    `json:"leaked_conns"`
+	}
+
+	poolStats struct {
+		Capacity          int     `json:"capacity"`
+		Open              int     `json:"open"`
+		Idle              int     `json:"idle"`
+		InUse             int     `json:"in_use"`
+		Dials             uint64  `json:"dials"`
+		DialFailures      uint64  `json:"dial_failures"`
+		BreakerRejections uint64  `json:"breaker_rejections"`
+		BorrowWaits       uint64  `json:"borrow_waits"`
+		BorrowTimeouts    uint64  `json:"borrow_timeouts"`
+		WaitMs            float64 `json:"wait_ms"`
+		ErrorClosed       uint64  `json:"error_closed"`
+		Reaped            uint64  `json:"reaped"`
+	}
+
+	leak struct {
+		HeldMs float64 `json:"held_ms"`
+		Stack  string  `json:"stack"`
+	}
+
+	errorEntry struct {
+		Time  time.Time `json:"time"`
+		Event string    `json:"event"`
+		Role  string    `json:"role"`
+		Addr  string    `json:"addr"`
+		Error string    `json:"error"`
+	}
+
+	command struct {
+		Time       time.Time `json:"time"`
+		Command    string    `json:"command"`
+		Key        string    `json:"key"`
+		Args       []string  `json:"args"`
+		DurationMs float64   `json:"duration_ms"`
+		Retries    int       `json:"retries"`
+		PoolWaitMs float64   `json:"pool_wait_ms"`
+		Error      string    `json:"error,omitempty"`
+	}
+)
+
+func newServer(state deepjoy.ServerState) server {
+	s := server{
+		Role:        state.Role,
+		Addr:        state.Addr,
+		Healthy:     state.Healthy,
+		Failures:    state.Failures,
+		BreakerOpen: state.Stats.BreakerOpen,
+		Pool: poolStats{
+			Capacity:          state.Stats.Capacity,
+			Open:              state.Stats.Open,
+			Idle:              state.Stats.Idle,
+			InUse:             state.Stats.InUse,
+			Dials:             state.Stats.Dials,
+			DialFailures:      state.Stats.DialFailures,
+			BreakerRejections: state.Stats.BreakerRejections,
+			BorrowWaits:       state.Stats.BorrowWaits,
+			BorrowTimeouts:    state.Stats.BorrowTimeouts,
+			WaitMs:            milliseconds(state.Stats.WaitDuration),
+			ErrorClosed:       state.Stats.ErrorClosed,
+			Reaped:            state.Stats.Reaped,
+		},
+		LeakedConns: []leak{},
+	}
+
+	if state.Role == deepjoy.RoleReplica && state.StalenessKnown {
+		staleness := milliseconds(state.Staleness)
+		s.StalenessMs = &staleness
+	}
+
+	for _, report := range state.Leaks {
+		s.LeakedConns = append(s.LeakedConns, leak{HeldMs: milliseconds(report.Held), Stack: report.Stack})
+	}
+
+	return s
+}
+
+func newCommand(entry deepjoy.CommandLogEntry) command {
+	c := command{
+		Time:       entry.Time,
+		Command:    entry.Command,
+		Key:        entry.Key,
+		Args:       entry.Args,
+		DurationMs: milliseconds(entry.Duration),
+		Retries:    entry.Retries,
+		PoolWaitMs: milliseconds(entry.PoolWait),
+	}
+
+	if entry.Err != nil {
+		c.Error = entry.Err.Error()
+	}
+
+	return c
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,87 @@
+package debughttp
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"ms":      func(v float64) string { return fmt.Sprintf("%.1fms", v) },
+	"join":    func(args []string) string { return strings.Join(args, " ") },
+	"servers": func(s snapshot) []server { return append([]server{s.Primary}, s.Replicas...) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>deepjoy</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+.bad { color: #b00; font-weight: bold; }
+pre { margin: 0; font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>deepjoy</h1>
+<p>Snapshot taken at {{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}. Also available as <a href="?format=json">JSON</a>.</p>
+
+<h2>Servers</h2>
+<table>
+<tr>
+<th>Role</th><th>Address</th><th>Healthy</th><th>Breaker</th><th>Staleness</th>
+<th>Capacity</th><th>Open</th><th>Idle</th><th>In use</th>
+<th>Dials</th><th>Dial failures</th><th>Breaker rejections</th>
+<th>Borrow waits</th><th>Borrow timeouts</th><th>Wait time</th>
+<th>Error closed</th><th>Reaped</th>
+</tr>
+{{range servers .}}
+<tr>
+<td>{{.Role}}</td>
+<td>{{.Addr}}</td>
+<td>{{if .Healthy}}yes{{else}}<span class="bad">no ({{.Failures}} failures)</span>{{end}}</td>
+<td>{{if .BreakerOpen}}<span class="bad">open</span>{{else}}closed{{end}}</td>
+<td>{{with .StalenessMs}}{{ms .}}{{else}}-{{end}}</td>
+<td>{{.Pool.Capacity}}</td>
+<td>{{.Pool.Open}}</td>
+<td>{{.Pool.Idle}}</td>
+<td>{{.Pool.InUse}}</td>
+<td>{{.Pool.Dials}}</td>
+<td>{{.Pool.DialFailures}}</td>
+<td>{{.Pool.BreakerRejections}}</td>
+<td>{{.Pool.BorrowWaits}}</td>
+<td>{{.Pool.BorrowTimeouts}}</td>
+<td>{{ms .Pool.WaitMs}}</td>
+<td>{{.Pool.ErrorClosed}}</td>
+<td>{{.Pool.Reaped}}</td>
+</tr>
+{{end}}
+</table>
+
+<h2>Leaked connections</h2>
+<table>
+<tr><th>Role</th><th>Address</th><th>Held</th><th>Borrowed by</th></tr>
+{{range $server := servers .}}{{range .LeakedConns}}
+<tr><td>{{$server.Role}}</td><td>{{$server.Addr}}</td><td>{{ms .HeldMs}}</td><td><pre>{{.Stack}}</pre></td></tr>
+{{end}}{{end}}
+</table>
+
+<h2>Recent errors</h2>
+<table>
+<tr><th>Time</th><th>Event</th><th>Role</th><th>Address</th><th>Error</th></tr>
+{{range .RecentErrors}}
+<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Event}}</td><td>{{.Role}}</td><td>{{.Addr}}</td><td>{{.Error}}</td></tr>
+{{end}}
+</table>
+
+<h2>Slow log</h2>
+<table>
+<tr><th>Time</th><th>Command</th><th>Arguments</th><th>Duration</th><th>Retries</th><th>Pool wait</th><th>Error</th></tr>
+{{range .SlowLog}}
+<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Command}}</td><td>{{join .Args}}</td><td>{{ms .DurationMs}}</td><td>{{.Retries}}</td><td>{{ms .PoolWaitMs}}</td><td>{{.Error}}</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
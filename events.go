@@ -0,0 +1,207 @@
+package deepjoy
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/efritz/glock"
+
+	"github.com/efritz/deepjoy/iface"
+)
+
+type (
+	// Event describes a change in the state of a client.
+	Event = iface.Event
+
+	// EventType distinguishes the lifecycle events published by a client.
+	EventType = iface.EventType
+
+	// EventSubscription receives the events published by a client.
+	EventSubscription = iface.EventSubscription
+
+	// eventBus delivers events to each subscription. The bus is shared by
+	// a client and its read replicas.
+	eventBus struct {
+		clock         glock.Clock
+		buffer        int
+		mutex         sync.RWMutex
+		subscriptions map[*eventSubscription]struct{}
+		closed        bool
+	}
+
+	eventSubscription struct {
+		bus     *eventBus
+		events  chan Event
+		types   map[EventType]struct{}
+		dropped uint64
+	}
+
+	// eventEmitter publishes events on behalf of the server with the given
+	// role and address. The zero value discards all events.
+	eventEmitter struct {
+		bus  *eventBus
+		role string
+		addr string
+	}
+)
+
+// The types of lifecycle events. See the iface package for a description
+// of each type.
+const (
+	DialSucceeded       = iface.DialSucceeded
+	DialFailed          = iface.DialFailed
+	BreakerStateChanged = iface.BreakerStateChanged
+	ConnectionDiscarded = iface.ConnectionDiscarded
+	RetryAttempt        = iface.RetryAttempt
+	PoolExhausted       = iface.PoolExhausted
+	ReplicaStateChanged = iface.ReplicaStateChanged
+	FailoverDetected    = iface.FailoverDetected
+	Closed              = iface.Closed
+)
+
+// defaultEventBufferSize is the default capacity of the channel of each
+// event subscription.
+const defaultEventBufferSize = 64
+
+func newEventBus(buffer int, clock glock.Clock) *eventBus {
+	if buffer < 1 {
+		buffer = 1
+	}
+
+	return &eventBus{
+		clock:         clock,
+		buffer:        buffer,
+		subscriptions: map[*eventSubscription]struct{}{},
+	}
+}
+
+// Create a subscription to the bus which receives events of the given types,
+// or every event if no type is given. Subscribing to a nil or closed bus
+// returns a subscription whose channel is already closed.
+func (b *eventBus) subscribe(types ...EventType) *eventSubscription {
+	if b == nil {
+		s := &eventSubscription{events: make(chan Event)}
+		close(s.events)
+		return s
+	}
+
+	s := &eventSubscription{bus: b, events: make(chan Event, b.buffer)}
+	if len(types) > 0 {
+		s.types = map[EventType]struct{}{}
+		for _, eventType := range types {
+			s.types[eventType] = struct{}{}
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		close(s.events)
+	} else {
+		b.subscriptions[s] = struct{}{}
+	}
+
+	return s
+}
+
+// Deliver the event to each subscription without blocking. The event is
+// dropped for each subscription whose channel is full.
+func (b *eventBus) publish(event Event) {
+	event.Time = b.clock.Now()
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for s := range b.subscriptions {
+		if !s.accepts(event.Type) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// Close the channel of each subscription. Events published afterwards are
+// discarded.
+func (b *eventBus) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	for s := range b.subscriptions {
+		close(s.events)
+	}
+
+	b.subscriptions = nil
+	b.closed = true
+}
+
+// Determine if the subscription receives events of the given type.
+func (s *eventSubscription) accepts(eventType EventType) bool {
+	if s.types == nil {
+		return true
+	}
+
+	_, ok := s.types[eventType]
+	return ok
+}
+
+func (s *eventSubscription) C() <-chan Event {
+	return s.events
+}
+
+func (s *eventSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *eventSubscription) Close() {
+	if s.bus == nil {
+		return
+	}
+
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+
+	if _, ok := s.bus.subscriptions[s]; ok {
+		delete(s.bus.subscriptions, s)
+		close(s.events)
+	}
+}
+
+// Publish the event with the role and address of the emitter.
+func (e eventEmitter) emit(event Event) {
+	if e.bus == nil {
+		return
+	}
+
+	event.Role = e.role
+	event.Addr = e.addr
+	e.bus.publish(event)
+}
+
+// Publish the Closed event. The bus is owned by the primary client, so
+// the subscriptions are closed once the primary has closed (after each of
+// its read replicas).
+func (e eventEmitter) close() {
+	e.emit(Event{Type: Closed})
+
+	if e.bus != nil && e.role == RolePrimary {
+		e.bus.close()
+	}
+}
+
+func (c *client) Events() EventSubscription {
+	return c.events.bus.subscribe()
+}
+
+func (c *replicaClient) Events() EventSubscription {
+	return c.primary.Events()
+}
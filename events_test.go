@@ -0,0 +1,268 @@
+package deepjoy
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	"github.com/efritz/overcurrent"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type EventsSuite struct{}
+
+func (s *EventsSuite) TestPublish(t sweet.T) {
+	var (
+		clock        = glock.NewMockClock()
+		bus          = newEventBus(10, clock)
+		subscription = bus.subscribe()
+	)
+
+	eventEmitter{bus: bus, role: RoleReplica, addr: "replica0"}.emit(Event{Type: DialFailed, Err: io.EOF})
+
+	var event Event
+	Eventually(subscription.C()).Should(Receive(&event))
+	Expect(event).To(Equal(Event{
+		Type: DialFailed,
+		Time: clock.Now(),
+		Role: RoleReplica,
+		Addr: "replica0",
+		Err:  io.EOF,
+	}))
+}
+
+func (s *EventsSuite) TestSubscribeToTypes(t sweet.T) {
+	var (
+		bus          = newEventBus(10, glock.NewMockClock())
+		subscription = bus.subscribe(ReplicaStateChanged)
+	)
+
+	bus.publish(Event{Type: RetryAttempt, Attempt: 1})
+	bus.publish(Event{Type: ReplicaStateChanged, Healthy: true})
+	bus.close()
+
+	var event Event
+	Eventually(subscription.C()).Should(Receive(&event))
+	Expect(event.Type).To(Equal(ReplicaStateChanged))
+	Eventually(subscription.C()).Should(BeClosed())
+	Expect(subscription.Dropped()).To(BeZero())
+}
+
+func (s *EventsSuite) TestSlowSubscription(t sweet.T) {
+	var (
+		bus  = newEventBus(2, glock.NewMockClock())
+		slow = bus.subscribe()
+		fast = bus.subscribe()
+		done = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 5; i++ {
+			bus.publish(Event{Type: RetryAttempt, Attempt: i + 1})
+			Expect((<-fast.C()).Attempt).To(Equal(i + 1))
+		}
+	}()
+
+	Eventually(done).Should(BeClosed())
+	Expect(slow.Dropped()).To(Equal(uint64(3)))
+	Expect(fast.Dropped()).To(BeZero())
+	Expect((<-slow.C()).Attempt).To(Equal(1))
+	Expect((<-slow.C()).Attempt).To(Equal(2))
+}
+
+func (s *EventsSuite) TestSubscriptionClose(t sweet.T) {
+	var (
+		bus          = newEventBus(10, glock.NewMockClock())
+		subscription = bus.subscribe()
+	)
+
+	subscription.Close()
+	subscription.Close()
+	bus.publish(Event{Type: Closed})
+	Expect(subscription.C()).To(BeClosed())
+}
+
+func (s *EventsSuite) TestClientClose(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		c    = makeClient(pool, nil)
+	)
+
+	c.events = eventEmitter{bus: newEventBus(10, glock.NewMockClock()), role: RolePrimary, addr: "primary"}
+	subscription := c.Events()
+	c.Close()
+
+	var event Event
+	Eventually(subscription.C()).Should(Receive(&event))
+	Expect(event.Type).To(Equal(Closed))
+	Expect(subscription.C()).To(BeClosed())
+	Expect(c.Events().C()).To(BeClosed())
+}
+
+func (s *EventsSuite) TestNoEvents(t sweet.T) {
+	Expect(makeClient(mocks.NewMockPool(), nil).Events().C()).To(BeClosed())
+}
+
+func (s *EventsSuite) TestRetryAttempt(t sweet.T) {
+	var (
+		pool  = mocks.NewMockPool()
+		conn1 = mocks.NewMockConn()
+		conn2 = mocks.NewMockConn()
+		clock = glock.NewMockClock()
+		c     = makeClient(pool, clock)
+	)
+
+	c.events = eventEmitter{bus: newEventBus(10, clock), role: RolePrimary, addr: "primary"}
+	subscription := c.Events()
+
+	pool.BorrowFunc.PushReturn(conn1, true)
+	pool.BorrowFunc.PushReturn(conn2, true)
+	conn1.DoFunc.SetDefaultReturn(nil, connErr{io.EOF})
+	conn2.DoFunc.SetDefaultReturn("bar", nil)
+
+	go func() {
+		// Unlock the after call in client
+		clock.BlockingAdvance(time.Second)
+	}()
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+
+	var discarded, retried Event
+	Eventually(subscription.C()).Should(Receive(&discarded))
+	Eventually(subscription.C()).Should(Receive(&retried))
+	Expect(discarded.Type).To(Equal(ConnectionDiscarded))
+	Expect(discarded.Err).To(Equal(connErr{io.EOF}))
+	Expect(retried.Type).To(Equal(RetryAttempt))
+	Expect(retried.Attempt).To(Equal(1))
+	Expect(retried.Addr).To(Equal("primary"))
+}
+
+func (s *EventsSuite) TestDialAndBreaker(t sweet.T) {
+	var (
+		clock    = glock.NewMockClock()
+		bus      = newEventBus(10, clock)
+		rejected = true
+		failed   = true
+		config   = &clientConfig{poolCapacity: 1, logger: NilLogger, clock: clock}
+	)
+
+	config.breakerFunc = func(f overcurrent.BreakerFunc) error {
+		if rejected {
+			rejected = false
+			return overcurrent.ErrCircuitOpen
+		}
+
+		return f(context.Background())
+	}
+
+	dialer := func() (Conn, error) {
+		if failed {
+			failed = false
+			return nil, io.EOF
+		}
+
+		return mocks.NewMockConn(), nil
+	}
+
+	var (
+		pool         = newPool(dialer, config, eventEmitter{bus: bus, role: RolePrimary, addr: "primary"})
+		subscription = bus.subscribe()
+	)
+
+	for i := 0; i < 2; i++ {
+		_, ok := pool.Borrow()
+		Expect(ok).To(BeFalse())
+	}
+
+	_, ok := pool.Borrow()
+	Expect(ok).To(BeTrue())
+
+	types := []EventType{}
+	for i := 0; i < 4; i++ {
+		var event Event
+		Eventually(subscription.C()).Should(Receive(&event))
+		types = append(types, event.Type)
+
+		if event.Type == BreakerStateChanged {
+			Expect(event.Healthy).To(Equal(i != 0))
+		}
+	}
+
+	Expect(types).To(Equal([]EventType{BreakerStateChanged, DialFailed, DialSucceeded, BreakerStateChanged}))
+}
+
+func (s *EventsSuite) TestPoolExhausted(t sweet.T) {
+	var (
+		clock        = glock.NewMockClock()
+		bus          = newEventBus(10, clock)
+		config       = &clientConfig{poolCapacity: 1, logger: NilLogger, breakerFunc: noopBreakerFunc, clock: clock, maxWaiters: 1}
+		pool         = newPool(testDial, config, eventEmitter{bus: bus, role: RolePrimary})
+		subscription = bus.subscribe()
+		errs         = make(chan error)
+	)
+
+	_, ok := pool.Borrow()
+	Expect(ok).To(BeTrue())
+
+	go func() {
+		_, err := pool.BorrowTimeoutErr(time.Second)
+		errs <- err
+	}()
+
+	Eventually(func() int {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		return len(pool.waiters)
+	}).Should(Equal(1))
+	_, err := pool.BorrowErr()
+	Expect(err).To(Equal(ErrPoolExhausted))
+
+	clock.BlockingAdvance(time.Second)
+	Eventually(errs).Should(Receive(Equal(ErrNoConnection)))
+
+	var event Event
+	for _, expected := range []error{ErrPoolExhausted, ErrNoConnection} {
+		Eventually(subscription.C()).Should(Receive(&event))
+		if event.Type == DialSucceeded {
+			Eventually(subscription.C()).Should(Receive(&event))
+		}
+
+		Expect(event.Type).To(Equal(PoolExhausted))
+		Expect(event.Err).To(Equal(expected))
+	}
+}
+
+func (s *EventsSuite) TestFailoverDetected(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		primaryConn = mocks.NewMockConn()
+		clock       = glock.NewMockClock()
+		primary     = makeClient(primaryPool, clock)
+		c           = makeReplicaClient(primary, clock)
+	)
+
+	primary.events = eventEmitter{bus: newEventBus(10, clock), role: RolePrimary, addr: "primary"}
+	subscription := c.Events()
+
+	primaryPool.BorrowTimeoutFunc.SetDefaultReturn(primaryConn, true)
+	primaryConn.DoFunc.PushReturn([]byte("role:master\r\nmaster_repl_offset:100\r\n"), nil)
+	primaryConn.DoFunc.PushReturn([]byte("role:slave\r\nslave_repl_offset:100\r\n"), nil)
+
+	c.check()
+	Consistently(subscription.C()).ShouldNot(Receive())
+
+	c.check()
+
+	var event Event
+	Eventually(subscription.C()).Should(Receive(&event))
+	Expect(event.Type).To(Equal(FailoverDetected))
+	Expect(event.Addr).To(Equal("primary"))
+	Expect(event.ServerRole).To(Equal("slave"))
+}
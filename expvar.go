@@ -0,0 +1,166 @@
+package deepjoy
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	expvarMetrics struct {
+		root    *expvar.Map
+		buckets []float64
+		mutex   sync.Mutex
+	}
+
+	expvarHistogram struct {
+		mutex  sync.Mutex
+		bounds []float64
+		counts []uint64
+		count  uint64
+		sum    float64
+	}
+
+	expvarHistogramJSON struct {
+		Count   uint64             `json:"count"`
+		Sum     float64            `json:"sum"`
+		Buckets []expvarBucketJSON `json:"buckets"`
+	}
+
+	expvarBucketJSON struct {
+		UpperBound float64 `json:"le"`
+		Count      uint64  `json:"count"`
+	}
+)
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the buckets of
+// the latency histograms reported by the metrics sinks in this package.
+var DefaultLatencyBuckets = []float64{
+	0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5,
+}
+
+// NewExpvarMetrics creates a Metrics sink which publishes measurements as an
+// expvar map with the given name. The map contains an entry for each metric,
+// which in turn contains an entry for each set of labels. Histograms are
+// published as a JSON object with a count, a sum, and cumulative bucket
+// counts. Like expvar.Publish, this function panics if the name is already
+// in use.
+func NewExpvarMetrics(name string) Metrics {
+	return &expvarMetrics{
+		root:    expvar.NewMap(name),
+		buckets: append([]float64(nil), DefaultLatencyBuckets...),
+	}
+}
+
+func (m *expvarMetrics) IncCounter(name string, labels MetricLabels) {
+	m.metric(name).AddFloat(seriesKey(labels), 1)
+}
+
+func (m *expvarMetrics) SetGauge(name string, labels MetricLabels, value float64) {
+	key := seriesKey(labels)
+	metric := m.metric(name)
+
+	m.mutex.Lock()
+	gauge, ok := metric.Get(key).(*expvar.Float)
+	if !ok {
+		gauge = new(expvar.Float)
+		metric.Set(key, gauge)
+	}
+	m.mutex.Unlock()
+
+	gauge.Set(value)
+}
+
+func (m *expvarMetrics) ObserveHistogram(name string, labels MetricLabels, value float64) {
+	key := seriesKey(labels)
+	metric := m.metric(name)
+
+	m.mutex.Lock()
+	histogram, ok := metric.Get(key).(*expvarHistogram)
+	if !ok {
+		histogram = &expvarHistogram{
+			bounds: m.buckets,
+			counts: make([]uint64, len(m.buckets)),
+		}
+
+		metric.Set(key, histogram)
+	}
+	m.mutex.Unlock()
+
+	histogram.observe(value)
+}
+
+// Get or create the map holding each series of the given metric.
+func (m *expvarMetrics) metric(name string) *expvar.Map {
+	if metric, ok := m.root.Get(name).(*expvar.Map); ok {
+		return metric
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	metric, ok := m.root.Get(name).(*expvar.Map)
+	if !ok {
+		metric = new(expvar.Map).Init()
+		m.root.Set(name, metric)
+	}
+
+	return metric
+}
+
+func (h *expvarHistogram) observe(value float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+
+	h.count++
+	h.sum += value
+}
+
+func (h *expvarHistogram) String() string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var (
+		cumulative uint64
+		buckets    = make([]expvarBucketJSON, 0, len(h.counts))
+	)
+
+	for i, count := range h.counts {
+		cumulative += count
+		buckets = append(buckets, expvarBucketJSON{UpperBound: h.bounds[i], Count: cumulative})
+	}
+
+	serialized, _ := json.Marshal(expvarHistogramJSON{
+		Count:   h.count,
+		Sum:     h.sum,
+		Buckets: buckets,
+	})
+
+	return string(serialized)
+}
+
+// Construct the key of a series from the non-empty labels.
+func seriesKey(labels MetricLabels) string {
+	parts := make([]string, 0, 4)
+	for _, pair := range [][2]string{
+		{"command", labels.Command},
+		{"role", labels.Role},
+		{"address", labels.Address},
+		{"outcome", labels.Outcome},
+	} {
+		if pair[1] != "" {
+			parts = append(parts, pair[0]+"="+strconv.Quote(pair[1]))
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,218 @@
+package deepjoy
+
+import (
+	"context"
+	"time"
+
+	"github.com/efritz/glock"
+)
+
+type (
+	// ClientHook receives callbacks around the operations performed by a
+	// client. Each Before method may return a derived context which is then
+	// passed to later hooks and to the matching After method. Hooks are
+	// invoked in the order in which they are registered before an operation,
+	// and in the reverse order after it, so that the first hook registered
+	// wraps all others. Embed NoopClientHook to implement a subset of the
+	// callbacks.
+	ClientHook interface {
+		// BeforeDo is invoked before a command is run.
+		BeforeDo(ctx context.Context, event *DoEvent) context.Context
+
+		// AfterDo is invoked after a command is run.
+		AfterDo(ctx context.Context, event *DoEvent)
+
+		// BeforePipeline is invoked before a pipeline is run.
+		BeforePipeline(ctx context.Context, event *PipelineEvent) context.Context
+
+		// AfterPipeline is invoked after a pipeline is run.
+		AfterPipeline(ctx context.Context, event *PipelineEvent)
+
+		// BeforeDial is invoked before a new connection is dialed.
+		BeforeDial(ctx context.Context, event *DialEvent) context.Context
+
+		// AfterDial is invoked after a new connection is dialed.
+		AfterDial(ctx context.Context, event *DialEvent)
+
+		// BeforeBorrow is invoked before a connection is borrowed from
+		// the pool.
+		BeforeBorrow(ctx context.Context, event *BorrowEvent) context.Context
+
+		// AfterBorrow is invoked after a connection is borrowed from the
+		// pool (or the borrow fails).
+		AfterBorrow(ctx context.Context, event *BorrowEvent)
+	}
+
+	// NoopClientHook implements every ClientHook method without effect.
+	NoopClientHook struct{}
+
+	// Command is a single command and its arguments.
+	Command struct {
+		Name string
+		Args []interface{}
+	}
+
+	// DoEvent describes a single command. The result, error, and duration
+	// are populated before AfterDo is invoked. The duration includes the
+	// time spent borrowing a connection.
+	DoEvent struct {
+		Command  string
+		Args     []interface{}
+		Result   interface{}
+		Err      error
+		Duration time.Duration
+	}
+
+	// PipelineEvent describes a pipeline of commands. The result, error,
+	// and duration are populated before AfterPipeline is invoked.
+	PipelineEvent struct {
+		Commands []Command
+		Result   interface{}
+		Err      error
+		Duration time.Duration
+	}
+
+	// DialEvent describes a dial of a new connection. The error and duration
+	// are populated before AfterDial is invoked.
+	DialEvent struct {
+		Err      error
+		Duration time.Duration
+	}
+
+	// BorrowEvent describes a borrow of a connection from the pool. The
+	// error and duration are populated before AfterBorrow is invoked.
+	BorrowEvent struct {
+		Err      error
+		Duration time.Duration
+	}
+
+	clientHooks []ClientHook
+)
+
+func (NoopClientHook) BeforeDo(ctx context.Context, event *DoEvent) context.Context {
+	return ctx
+}
+
+func (NoopClientHook) AfterDo(ctx context.Context, event *DoEvent) {
+}
+
+func (NoopClientHook) BeforePipeline(ctx context.Context, event *PipelineEvent) context.Context {
+	return ctx
+}
+
+func (NoopClientHook) AfterPipeline(ctx context.Context, event *PipelineEvent) {
+}
+
+func (NoopClientHook) BeforeDial(ctx context.Context, event *DialEvent) context.Context {
+	return ctx
+}
+
+func (NoopClientHook) AfterDial(ctx context.Context, event *DialEvent) {
+}
+
+func (NoopClientHook) BeforeBorrow(ctx context.Context, event *BorrowEvent) context.Context {
+	return ctx
+}
+
+func (NoopClientHook) AfterBorrow(ctx context.Context, event *BorrowEvent) {
+}
+
+// Run a command wrapped in the registered hooks.
+func (h clientHooks) do(ctx context.Context, clock glock.Clock, command string, args []interface{}, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if len(h) == 0 {
+		return f(ctx)
+	}
+
+	event := &DoEvent{Command: command, Args: args}
+	for _, hook := range h {
+		ctx = hook.BeforeDo(ctx, event)
+	}
+
+	start := clock.Now()
+	event.Result, event.Err = f(ctx)
+	event.Duration = clock.Since(start)
+
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterDo(ctx, event)
+	}
+
+	return event.Result, event.Err
+}
+
+// Run a pipeline wrapped in the registered hooks.
+func (h clientHooks) pipeline(ctx context.Context, clock glock.Clock, commands []commandPair, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if len(h) == 0 {
+		return f(ctx)
+	}
+
+	event := &PipelineEvent{Commands: make([]Command, 0, len(commands))}
+	for _, command := range commands {
+		event.Commands = append(event.Commands, Command{Name: command.command, Args: command.args})
+	}
+
+	for _, hook := range h {
+		ctx = hook.BeforePipeline(ctx, event)
+	}
+
+	start := clock.Now()
+	event.Result, event.Err = f(ctx)
+	event.Duration = clock.Since(start)
+
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterPipeline(ctx, event)
+	}
+
+	return event.Result, event.Err
+}
+
+// Borrow a connection wrapped in the registered hooks.
+func (h clientHooks) borrow(ctx context.Context, clock glock.Clock, f func() (Conn, error)) (Conn, error) {
+	if len(h) == 0 {
+		return f()
+	}
+
+	event := &BorrowEvent{}
+	for _, hook := range h {
+		ctx = hook.BeforeBorrow(ctx, event)
+	}
+
+	start := clock.Now()
+	conn, err := f()
+	event.Err = err
+	event.Duration = clock.Since(start)
+
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterBorrow(ctx, event)
+	}
+
+	return conn, err
+}
+
+// Wrap a dialer so that each dial invokes the registered hooks.
+func makeHookedDialer(dialer DialFunc, h clientHooks, clock glock.Clock) DialFunc {
+	if len(h) == 0 {
+		return dialer
+	}
+
+	return func() (Conn, error) {
+		var (
+			ctx   = context.Background()
+			event = &DialEvent{}
+		)
+
+		for _, hook := range h {
+			ctx = hook.BeforeDial(ctx, event)
+		}
+
+		start := clock.Now()
+		conn, err := dialer()
+		event.Err = err
+		event.Duration = clock.Since(start)
+
+		for i := len(h) - 1; i >= 0; i-- {
+			h[i].AfterDial(ctx, event)
+		}
+
+		return conn, err
+	}
+}
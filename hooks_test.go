@@ -0,0 +1,212 @@
+package deepjoy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	. "github.com/efritz/go-mockgen/matchers"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type HookSuite struct{}
+
+type (
+	recordingHook struct {
+		NoopClientHook
+		name    string
+		calls   *[]string
+		events  []*DoEvent
+		borrows []*BorrowEvent
+	}
+
+	hookKey struct{}
+
+	recordingConn struct {
+		Conn
+		name  string
+		calls *[]string
+	}
+)
+
+func (h *recordingHook) BeforeDo(ctx context.Context, event *DoEvent) context.Context {
+	*h.calls = append(*h.calls, h.name+":before-do")
+	return context.WithValue(ctx, hookKey{}, h.name)
+}
+
+func (h *recordingHook) AfterDo(ctx context.Context, event *DoEvent) {
+	*h.calls = append(*h.calls, fmt.Sprintf("%s:after-do:%s", h.name, ctx.Value(hookKey{})))
+	h.events = append(h.events, event)
+}
+
+func (h *recordingHook) BeforeBorrow(ctx context.Context, event *BorrowEvent) context.Context {
+	*h.calls = append(*h.calls, h.name+":before-borrow")
+	return ctx
+}
+
+func (h *recordingHook) AfterBorrow(ctx context.Context, event *BorrowEvent) {
+	*h.calls = append(*h.calls, h.name+":after-borrow")
+	h.borrows = append(h.borrows, event)
+}
+
+func (c *recordingConn) Do(command string, args ...interface{}) (interface{}, error) {
+	*c.calls = append(*c.calls, c.name)
+	return c.Conn.Do(command, args...)
+}
+
+func (s *HookSuite) TestDoHooks(t sweet.T) {
+	var (
+		calls = []string{}
+		hook1 = &recordingHook{name: "a", calls: &calls}
+		hook2 = &recordingHook{name: "b", calls: &calls}
+		pool  = mocks.NewMockPool()
+		conn  = mocks.NewMockConn()
+		c     = makeClient(pool, nil)
+	)
+
+	c.hooks = clientHooks{hook1, hook2}
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn("bar", nil)
+
+	result, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal("bar"))
+
+	Expect(calls).To(Equal([]string{
+		"a:before-do",
+		"b:before-do",
+		"a:before-borrow",
+		"b:before-borrow",
+		"b:after-borrow",
+		"a:after-borrow",
+		"b:after-do:b",
+		"a:after-do:b",
+	}))
+
+	Expect(hook1.events).To(HaveLen(1))
+	Expect(hook1.events[0].Command).To(Equal("GET"))
+	Expect(hook1.events[0].Args).To(Equal([]interface{}{"foo"}))
+	Expect(hook1.events[0].Result).To(Equal("bar"))
+	Expect(hook1.events[0].Err).To(BeNil())
+}
+
+func (s *HookSuite) TestDoHooksError(t sweet.T) {
+	var (
+		calls = []string{}
+		hook  = &recordingHook{name: "a", calls: &calls}
+		pool  = mocks.NewMockPool()
+		c     = makeClient(pool, nil)
+	)
+
+	c.hooks = clientHooks{hook}
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(Equal(ErrNoConnection))
+	Expect(hook.events).To(HaveLen(1))
+	Expect(hook.events[0].Err).To(Equal(ErrNoConnection))
+}
+
+func (s *HookSuite) TestHookDurations(t sweet.T) {
+	var (
+		calls = []string{}
+		hook  = &recordingHook{name: "a", calls: &calls}
+		pool  = mocks.NewMockPool()
+		conn  = mocks.NewMockConn()
+		clock = glock.NewMockClock()
+		c     = makeClient(pool, clock)
+	)
+
+	c.hooks = clientHooks{hook}
+
+	pool.BorrowFunc.SetDefaultHook(func() (Conn, bool) {
+		clock.Advance(time.Millisecond * 10)
+		return conn, true
+	})
+
+	conn.DoFunc.SetDefaultHook(func(command string, args ...interface{}) (interface{}, error) {
+		clock.Advance(time.Millisecond * 5)
+		return "bar", nil
+	})
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+	Expect(hook.borrows).To(HaveLen(1))
+	Expect(hook.borrows[0].Duration).To(Equal(time.Millisecond * 10))
+	Expect(hook.events).To(HaveLen(1))
+	Expect(hook.events[0].Duration).To(Equal(time.Millisecond * 15))
+}
+
+func (s *HookSuite) TestPipelineHooks(t sweet.T) {
+	var (
+		events = []*PipelineEvent{}
+		hook   = &pipelineHook{events: &events}
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		c      = makeClient(pool, nil)
+	)
+
+	c.hooks = clientHooks{hook}
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn([]interface{}{"OK"}, nil)
+
+	pipeline := c.Pipeline()
+	pipeline.Add("SET", "foo", "bar")
+	_, err := pipeline.Run()
+	Expect(err).To(BeNil())
+
+	Expect(events).To(HaveLen(1))
+	Expect(events[0].Commands).To(Equal([]Command{{Name: "SET", Args: []interface{}{"foo", "bar"}}}))
+	Expect(events[0].Result).To(Equal([]interface{}{"OK"}))
+}
+
+func (s *HookSuite) TestDialHooksAndMiddleware(t sweet.T) {
+	var (
+		calls  = []string{}
+		dials  = []*DialEvent{}
+		conn   = mocks.NewMockConn()
+		client = NewClient(
+			"localhost:6379",
+			WithLogger(NilLogger),
+			WithDialerFactory(func(addrs []string) DialFunc {
+				return func() (Conn, error) { return conn, nil }
+			}),
+			WithClientHook(&dialHook{events: &dials}),
+			WithConnMiddleware(func(next Conn) Conn { return &recordingConn{Conn: next, name: "outer", calls: &calls} }),
+			WithConnMiddleware(func(next Conn) Conn { return &recordingConn{Conn: next, name: "inner", calls: &calls} }),
+		)
+	)
+
+	defer client.Close()
+
+	_, err := client.Do("PING")
+	Expect(err).To(BeNil())
+	Expect(calls).To(Equal([]string{"outer", "inner"}))
+	Expect(conn.DoFunc).To(BeCalledWith("PING"))
+
+	Expect(dials).To(HaveLen(1))
+	Expect(dials[0].Err).To(BeNil())
+}
+
+type (
+	pipelineHook struct {
+		NoopClientHook
+		events *[]*PipelineEvent
+	}
+
+	dialHook struct {
+		NoopClientHook
+		events *[]*DialEvent
+	}
+)
+
+func (h *pipelineHook) AfterPipeline(ctx context.Context, event *PipelineEvent) {
+	*h.events = append(*h.events, event)
+}
+
+func (h *dialHook) AfterDial(ctx context.Context, event *DialEvent) {
+	*h.events = append(*h.events, event)
+}
@@ -1,10 +1,21 @@
 package iface
 
+import (
+	"context"
+	"time"
+)
+
 // Client is a goroutine-safe, minimal, and pooled Redis client.
 type Client interface {
 	// Close will close all open connections to the remote Redis server.
 	Close()
 
+	// CloseContext is like Close, but stops waiting for borrowed connections
+	// to be returned when the given context is done. Connections which are
+	// still borrowed at that point are closed, and the context error is
+	// returned.
+	CloseContext(ctx context.Context) error
+
 	// ReadReplica returns a host that points to the set of configured
 	// read replicas. If no read replicas are configured, this returns
 	// the current client. The client returned from this method does NOT
@@ -12,10 +23,36 @@ type Client interface {
 	// also close replica clients).
 	ReadReplica() Client
 
+	// ReadReplicaWithin returns a client like ReadReplica, but which only
+	// sends commands to read replicas estimated to lag behind the primary
+	// by no more than the given duration. If no read replica qualifies,
+	// the command is sent to the primary. The replication lag of each read
+	// replica is refreshed on the replica health check interval.
+	ReadReplicaWithin(maxStaleness time.Duration) Client
+
+	// ReadReplicaAfter returns a client like ReadReplica, but which only
+	// sends commands to read replicas which have replicated the write that
+	// produced the given token. If no read replica qualifies, the command
+	// is sent to the primary.
+	ReadReplicaAfter(token ConsistencyToken) Client
+
 	// Do runs the command on the remote Redis server and returns its raw
 	// response.
 	Do(command string, args ...interface{}) (interface{}, error)
 
+	// DoContext is like Do, but the given context is passed to the registered
+	// hooks and tracer, and bounds the time spent borrowing a connection and
+	// backing off between retries. If the context is done before a connection
+	// is borrowed, the context error is returned.
+	DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error)
+
+	// DoWithToken runs the command on the remote Redis server like Do and
+	// also returns a token identifying the position of the primary in its
+	// replication stream after the command completes. The token can later
+	// be passed to ReadReplicaAfter to read the effects of the command. The
+	// command is always run on the primary.
+	DoWithToken(command string, args ...interface{}) (interface{}, ConsistencyToken, error)
+
 	// Pipeline returns a builder object to which commands can be attached.
 	// All commands in the pipeline are sent to the remote server in a
 	// single request and all results will be returned in a single response.
@@ -24,4 +61,98 @@ type Client interface {
 	// run atomically, bundle them in a Lua script and run it on the remote
 	// server with the EVAL command.
 	Pipeline() Pipeline
+
+	// Stats returns a snapshot of the connection pools of the primary and
+	// of each read replica. Calling this method on a client returned from
+	// a ReadReplica method returns the stats of the source client.
+	Stats() ClientStats
+
+	// Events returns a new subscription to the lifecycle events of the
+	// client and of its read replicas. Calling this method on a client
+	// returned from a ReadReplica method subscribes to the events of the
+	// source client.
+	Events() EventSubscription
+
+	// State returns a snapshot of the configuration and health of the
+	// primary and of each read replica, for diagnostics. Calling this
+	// method on a client returned from a ReadReplica method returns the
+	// state of the source client.
+	State() ClientState
+}
+
+// ClientStats is a snapshot of the connection pools used by a client.
+type ClientStats struct {
+	// Primary is the state of the primary's connection pool.
+	Primary PoolStats
+
+	// Replicas is the state of the connection pool of each read replica,
+	// keyed by address.
+	Replicas map[string]PoolStats
+
+	// Total is the sum of the primary and read replica pool stats.
+	Total PoolStats
+}
+
+// ClientState describes the servers used by a client.
+type ClientState struct {
+	// Primary is the state of the primary.
+	Primary ServerState
+
+	// Replicas is the state of each read replica, in the order in which
+	// the replicas were configured.
+	Replicas []ServerState
+}
+
+// ServerState describes a single server used by a client.
+type ServerState struct {
+	// Role is the role of the server (primary or replica).
+	Role string
+
+	// Addr is the configured address of the server.
+	Addr string
+
+	// Healthy is false if the server is a read replica which has been
+	// ejected from the rotation. The primary is always healthy.
+	Healthy bool
+
+	// Failures is the number of consecutive failures of a read replica.
+	Failures int
+
+	// Staleness is the estimated replication lag of a read replica. The
+	// value is only meaningful when StalenessKnown is true.
+	Staleness      time.Duration
+	StalenessKnown bool
+
+	// Stats is the state of the server's connection pool.
+	Stats PoolStats
+
+	// Leaks describes each connection which has been borrowed from the
+	// pool for longer than the leak detection threshold. This is empty
+	// unless leak detection is enabled.
+	Leaks []LeakReport
+}
+
+// LeakReport describes a connection which has been borrowed from a pool
+// for longer than the leak detection threshold.
+type LeakReport struct {
+	// Held is the time since the connection was borrowed.
+	Held time.Duration
+
+	// Stack is the stack of the goroutine which borrowed the connection.
+	Stack string
+}
+
+// ConsistencyToken identifies a position in the replication stream of the
+// primary. A read replica which has processed the stream up to the token's
+// offset has observed every write which completed before the token was
+// issued.
+type ConsistencyToken struct {
+	// Offset is the replication offset of the primary.
+	Offset int64
+
+	// Replicas is the number of replicas which acknowledged the write via
+	// the WAIT command. This value is zero unless the client is configured
+	// to wait for replicas after a write. It is informational only; reads
+	// made via ReadReplicaAfter always check the offset of the replica.
+	Replicas int
 }
@@ -0,0 +1,111 @@
+package iface
+
+import "time"
+
+// EventType distinguishes the lifecycle events published by a client.
+type EventType int
+
+const (
+	// DialSucceeded is published when a new connection is established.
+	DialSucceeded EventType = iota
+
+	// DialFailed is published when a new connection cannot be established.
+	DialFailed
+
+	// BreakerStateChanged is published when the circuit breaker wrapping
+	// the dials of a pool begins or stops rejecting dials.
+	BreakerStateChanged
+
+	// ConnectionDiscarded is published when a connection is closed rather
+	// than returned to the pool because it failed or its session could not
+	// be restored.
+	ConnectionDiscarded
+
+	// RetryAttempt is published when a command or pipeline is retried.
+	RetryAttempt
+
+	// PoolExhausted is published when a borrower is turned away because
+	// the pool has no connection to hand out.
+	PoolExhausted
+
+	// ReplicaStateChanged is published when a read replica is ejected from
+	// or restored to the rotation.
+	ReplicaStateChanged
+
+	// FailoverDetected is published when the health check finds that the
+	// role reported by a server has changed.
+	FailoverDetected
+
+	// Closed is published when a client is closed.
+	Closed
+)
+
+var eventTypeNames = map[EventType]string{
+	DialSucceeded:       "DialSucceeded",
+	DialFailed:          "DialFailed",
+	BreakerStateChanged: "BreakerStateChanged",
+	ConnectionDiscarded: "ConnectionDiscarded",
+	RetryAttempt:        "RetryAttempt",
+	PoolExhausted:       "PoolExhausted",
+	ReplicaStateChanged: "ReplicaStateChanged",
+	FailoverDetected:    "FailoverDetected",
+	Closed:              "Closed",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+
+	return "Unknown"
+}
+
+// Event describes a change in the state of a client. Fields which do not
+// apply to the type of event are left zero.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+
+	// Time is the time at which the event was published.
+	Time time.Time
+
+	// Role is the role of the server to which the event applies (primary
+	// or replica), and Addr is its address.
+	Role string
+	Addr string
+
+	// Err is the error which caused the event, if any.
+	Err error
+
+	// Duration is the time taken by a dial.
+	Duration time.Duration
+
+	// Attempt is the one-based index of the attempt which failed and is
+	// about to be retried.
+	Attempt int
+
+	// Healthy is true when a breaker stops rejecting dials or a replica is
+	// restored to the rotation, and false when a breaker begins rejecting
+	// dials or a replica is ejected.
+	Healthy bool
+
+	// ServerRole is the role newly reported by the server (e.g. master or
+	// slave) when a failover is detected.
+	ServerRole string
+}
+
+// EventSubscription receives the events published by a client.
+type EventSubscription interface {
+	// C returns the channel on which events are delivered. Events are
+	// dropped rather than delivered when the channel is full, so that a
+	// slow consumer never blocks the client. The channel is closed after
+	// the Closed event of the client or when the subscription is closed.
+	C() <-chan Event
+
+	// Dropped returns the number of events which were not delivered because
+	// the channel was full.
+	Dropped() uint64
+
+	// Close stops the delivery of events and closes the channel.
+	Close()
+}
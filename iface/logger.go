@@ -5,3 +5,21 @@ type Logger interface {
 	// Printf logs a message. Arguments should be handled in the manner of fmt.Printf.
 	Printf(format string, args ...interface{})
 }
+
+// LeveledLogger is an interface to a structured logger the client writes
+// to. Each method logs a constant message along with a list of alternating
+// keys and values (e.g. "addr", addr, "error", err) in the manner of slog.
+type LeveledLogger interface {
+	// Debug logs a message about routine operation. Messages logged for each
+	// command are logged at this level.
+	Debug(msg string, fields ...interface{})
+
+	// Info logs a message about a change in the state of the client.
+	Info(msg string, fields ...interface{})
+
+	// Warn logs a message about a recoverable failure.
+	Warn(msg string, fields ...interface{})
+
+	// Error logs a message about a failure which may require intervention.
+	Error(msg string, fields ...interface{})
+}
@@ -1,5 +1,7 @@
 package iface
 
+import "context"
+
 // Pipeline wraps an ordered sequence of commands to be processed
 // with a single request/response exchange. This reduces bandwidth
 // and latency around communication with the remote server.
@@ -12,4 +14,9 @@ type Pipeline interface {
 	// single request and return a slice of the results of each
 	// command.
 	Run() (interface{}, error)
+
+	// RunContext is like Run, but the given context is passed to the
+	// registered hooks and tracer, and bounds the time spent borrowing a
+	// connection and backing off between retries.
+	RunContext(ctx context.Context) (interface{}, error)
 }
@@ -1,16 +1,31 @@
 package iface
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
-// Pool abstracts a fixed-size Redis connection pool.
+// Pool abstracts a Redis connection pool.
 type Pool interface {
 	// Close will drain all available connections from the pool.
-	// Every live connection is closed. This method blocks.
+	// Every live connection is closed. This method blocks until
+	// every borrowed connection is returned to the pool.
 	Close()
 
+	// CloseContext is like Close, but stops waiting for borrowed
+	// connections when the given context is done. Connections which
+	// are still borrowed at that point are closed, and the context
+	// error is returned. New borrows fail with ErrPoolClosed once the
+	// pool begins to close, and connections released after the pool
+	// is closed are closed rather than returned to the pool.
+	CloseContext(ctx context.Context) error
+
 	// Borrow will block until a connection value is available in
 	// the pool. If the connection is nil, then a new connection
-	// is dialed in its place.
+	// is dialed in its place. Blocked borrowers are served in the
+	// order in which they began waiting. The pair (nil, false) is
+	// returned if no connection can be borrowed. Use BorrowErr to
+	// determine the reason.
 	Borrow() (Conn, bool)
 
 	// BorrowTimeout is like borrow, but will return the pair
@@ -18,9 +33,90 @@ type Pool interface {
 	// given timeout elapses.
 	BorrowTimeout(timeout time.Duration) (Conn, bool)
 
+	// BorrowErr is like Borrow, but returns the reason that no
+	// connection could be borrowed. If the maximum number of
+	// borrowers are already waiting, ErrPoolExhausted is returned
+	// immediately. ErrPoolClosed is returned if the pool is closing,
+	// and ErrNoConnection is returned if a new connection cannot be
+	// dialed.
+	BorrowErr() (Conn, error)
+
+	// BorrowTimeoutErr is like BorrowTimeout, but returns the reason
+	// that no connection could be borrowed. ErrNoConnection is also
+	// returned if no value is returned to the pool before the given
+	// timeout elapses.
+	BorrowTimeoutErr(timeout time.Duration) (Conn, error)
+
 	// Release returns a connection to the pool. This method must
 	// be called exactly once for each call to a Borrow method. A
-	// connection which encountered an error should be returned to
-	// the pool as a nil value.
+	// connection which encountered an error must be passed to
+	// Discard instead. Releasing a nil value in its place is not
+	// supported, and is logged as an error.
 	Release(conn Conn)
+
+	// Discard closes a borrowed connection which encountered an
+	// error and frees its place in the pool, so that a new connection
+	// is dialed in its place. It may be called in place of Release,
+	// but not in addition to it.
+	Discard(conn Conn)
+
+	// Resize changes the capacity of the pool. When the capacity grows,
+	// new connections are dialed as they are needed. When the capacity
+	// shrinks, idle connections in excess of the new capacity are closed
+	// immediately. Borrowed connections are not interrupted, but those in
+	// excess of the new capacity are closed when they are released.
+	Resize(capacity int)
+
+	// Stats returns a snapshot of the pool's connection counts and
+	// cumulative counters.
+	Stats() PoolStats
+}
+
+// PoolStats is a snapshot of the state of a connection pool. Counts are
+// the values at the time of the snapshot, and all other values are totals
+// since the pool was created.
+type PoolStats struct {
+	// Capacity is the maximum number of open connections.
+	Capacity int
+
+	// Open is the number of open connections (idle or in use).
+	Open int
+
+	// Idle is the number of open connections which are not borrowed.
+	Idle int
+
+	// InUse is the number of open connections which are borrowed.
+	InUse int
+
+	// Dials is the number of attempts to dial a new connection.
+	Dials uint64
+
+	// DialFailures is the number of dial attempts which failed.
+	DialFailures uint64
+
+	// BreakerRejections is the number of dials which were not attempted
+	// because the circuit breaker was open.
+	BreakerRejections uint64
+
+	// BreakerOpen is true if the last dial was rejected by the circuit
+	// breaker (and no dial has succeeded since).
+	BreakerOpen bool
+
+	// BorrowWaits is the number of borrows which had to wait for a
+	// connection to be released.
+	BorrowWaits uint64
+
+	// BorrowTimeouts is the number of borrows which gave up waiting.
+	BorrowTimeouts uint64
+
+	// WaitDuration is the total time spent waiting by borrowers.
+	WaitDuration time.Duration
+
+	// ErrorClosed is the number of connections which were closed after
+	// encountering an error or failing a health check.
+	ErrorClosed uint64
+
+	// Reaped is the number of connections which were closed because they
+	// expired or exceeded the maximum number of idle connections.
+	Reaped uint64
 }
@@ -0,0 +1,159 @@
+package deepjoy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// replicationInfo is the subset of the replication section of the
+	// INFO command used to determine the staleness of a replica.
+	replicationInfo struct {
+		role          string
+		offset        int64
+		linkUp        bool
+		lastIOSeconds int
+	}
+
+	// offsetTracker records the replication offset of the primary over
+	// time so that the offset of a replica can be translated into the
+	// amount of time it lags behind the primary.
+	offsetTracker struct {
+		samples  []offsetSample
+		capacity int
+		mutex    sync.RWMutex
+	}
+
+	offsetSample struct {
+		at     time.Time
+		offset int64
+	}
+)
+
+// maxStaleness is the staleness assigned to a replica which has lost its
+// link to the primary.
+const maxStaleness = time.Duration(math.MaxInt64)
+
+// Parse the reply of an `INFO replication` command.
+func parseReplicationInfo(reply interface{}) (replicationInfo, error) {
+	var payload []byte
+	switch v := reply.(type) {
+	case []byte:
+		payload = v
+	case string:
+		payload = []byte(v)
+	default:
+		return replicationInfo{}, fmt.Errorf("unexpected INFO reply type %T", reply)
+	}
+
+	info := replicationInfo{
+		offset:        -1,
+		lastIOSeconds: -1,
+	}
+
+	// A replica reports both its own master_repl_offset and the offset
+	// it has processed from the primary. Prefer the latter if present.
+	masterOffset, replicaOffset := int64(-1), int64(-1)
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch key, value := parts[0], parts[1]; key {
+		case "role":
+			info.role = value
+		case "master_link_status":
+			info.linkUp = value == "up"
+		case "master_last_io_seconds_ago":
+			info.lastIOSeconds, _ = strconv.Atoi(value)
+		case "master_repl_offset":
+			masterOffset, _ = strconv.ParseInt(value, 10, 64)
+		case "slave_repl_offset":
+			replicaOffset, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
+	if info.role == "" {
+		return replicationInfo{}, fmt.Errorf("malformed INFO reply")
+	}
+
+	info.offset = masterOffset
+	if replicaOffset >= 0 {
+		info.offset = replicaOffset
+	}
+
+	return info, nil
+}
+
+func newOffsetTracker(capacity int) *offsetTracker {
+	return &offsetTracker{
+		samples:  make([]offsetSample, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// Record the replication offset of the primary at the given time.
+func (t *offsetTracker) record(at time.Time, offset int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.samples) == t.capacity {
+		t.samples = append(t.samples[:0], t.samples[1:]...)
+	}
+
+	t.samples = append(t.samples, offsetSample{at: at, offset: offset})
+}
+
+// Determine if no offsets of the primary have been recorded.
+func (t *offsetTracker) empty() bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return len(t.samples) == 0
+}
+
+// Estimate how far behind the primary a replica at the given offset was
+// at the time of the most recent sample. Returns false if there are no
+// samples, or if the replica has not caught up to any of the recorded
+// samples (in which case there is no bound on its staleness).
+func (t *offsetTracker) staleness(offset int64) (time.Duration, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if len(t.samples) == 0 {
+		return 0, false
+	}
+
+	latest := t.samples[len(t.samples)-1]
+	if offset >= latest.offset {
+		return 0, true
+	}
+
+	// Find the first sample which is ahead of the replica. The first write
+	// the replica is missing occurred between that sample and the previous
+	// one - we interpolate between the two assuming a constant write rate.
+
+	i := 0
+	for i < len(t.samples) && t.samples[i].offset <= offset {
+		i++
+	}
+
+	if i == 0 {
+		return 0, false
+	}
+
+	prev, next := t.samples[i-1], t.samples[i]
+	ratio := float64(offset-prev.offset) / float64(next.offset-prev.offset)
+	missingSince := prev.at.Add(time.Duration(ratio * float64(next.at.Sub(prev.at))))
+
+	return latest.at.Sub(missingSince), true
+}
@@ -0,0 +1,113 @@
+package deepjoy
+
+import (
+	"time"
+
+	"github.com/aphistic/sweet"
+	. "github.com/onsi/gomega"
+)
+
+type LagSuite struct{}
+
+func (s *LagSuite) TestParseReplicationInfoReplica(t sweet.T) {
+	info, err := parseReplicationInfo([]byte("# Replication\r\n" +
+		"role:slave\r\n" +
+		"master_host:10.0.0.1\r\n" +
+		"master_port:6379\r\n" +
+		"master_link_status:up\r\n" +
+		"master_last_io_seconds_ago:3\r\n" +
+		"slave_repl_offset:1523\r\n" +
+		"master_repl_offset:1520\r\n",
+	))
+
+	Expect(err).To(BeNil())
+	Expect(info).To(Equal(replicationInfo{
+		role:          "slave",
+		offset:        1523,
+		linkUp:        true,
+		lastIOSeconds: 3,
+	}))
+}
+
+func (s *LagSuite) TestParseReplicationInfoPrimary(t sweet.T) {
+	info, err := parseReplicationInfo("# Replication\r\nrole:master\r\nconnected_slaves:2\r\nmaster_repl_offset:8832\r\n")
+	Expect(err).To(BeNil())
+	Expect(info.role).To(Equal("master"))
+	Expect(info.offset).To(Equal(int64(8832)))
+	Expect(info.linkUp).To(BeFalse())
+}
+
+func (s *LagSuite) TestParseReplicationInfoMalformed(t sweet.T) {
+	_, err := parseReplicationInfo([]byte("garbage"))
+	Expect(err).To(MatchError("malformed INFO reply"))
+
+	_, err = parseReplicationInfo(int64(3))
+	Expect(err).To(MatchError("unexpected INFO reply type int64"))
+}
+
+func (s *LagSuite) TestStalenessNoSamples(t sweet.T) {
+	_, ok := newOffsetTracker(10).staleness(100)
+	Expect(ok).To(BeFalse())
+}
+
+func (s *LagSuite) TestStalenessCaughtUp(t sweet.T) {
+	var (
+		now     = time.Now()
+		tracker = newOffsetTracker(10)
+	)
+
+	tracker.record(now, 100)
+	tracker.record(now.Add(time.Second), 200)
+
+	Expect(knownStaleness(tracker, 200)).To(Equal(time.Duration(0)))
+	Expect(knownStaleness(tracker, 250)).To(Equal(time.Duration(0)))
+}
+
+func (s *LagSuite) TestStalenessInterpolation(t sweet.T) {
+	var (
+		now     = time.Now()
+		tracker = newOffsetTracker(10)
+	)
+
+	tracker.record(now, 100)
+	tracker.record(now.Add(time.Second*4), 500)
+	tracker.record(now.Add(time.Second*10), 500)
+
+	// Missing writes since one second after the first sample
+	Expect(knownStaleness(tracker, 200)).To(Equal(time.Second * 9))
+	Expect(knownStaleness(tracker, 100)).To(Equal(time.Second * 10))
+}
+
+func (s *LagSuite) TestStalenessBehindHistory(t sweet.T) {
+	var (
+		now     = time.Now()
+		tracker = newOffsetTracker(2)
+	)
+
+	tracker.record(now, 100)
+	tracker.record(now.Add(time.Second), 200)
+	tracker.record(now.Add(time.Second*2), 300)
+
+	// Oldest sample was evicted
+	_, ok := tracker.staleness(150)
+	Expect(ok).To(BeFalse())
+	Expect(knownStaleness(tracker, 250)).To(Equal(time.Second / 2))
+}
+
+func (s *LagSuite) TestStalenessBehindSingleSample(t sweet.T) {
+	tracker := newOffsetTracker(10)
+	tracker.record(time.Now(), 100)
+
+	_, ok := tracker.staleness(50)
+	Expect(ok).To(BeFalse())
+	Expect(knownStaleness(tracker, 100)).To(Equal(time.Duration(0)))
+}
+
+//
+// Helpers
+
+func knownStaleness(tracker *offsetTracker, offset int64) time.Duration {
+	staleness, ok := tracker.staleness(offset)
+	Expect(ok).To(BeTrue())
+	return staleness
+}
@@ -0,0 +1,147 @@
+package deepjoy
+
+import (
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/efritz/glock"
+)
+
+type (
+	// leakDetector tracks the connections lent out by a pool in order to
+	// find borrowers which do not release a connection, which release a
+	// connection more than once, or which release a connection which did
+	// not come from the pool.
+	leakDetector struct {
+		threshold time.Duration
+		logger    LeveledLogger
+		clock     glock.Clock
+		mutex     sync.Mutex
+		borrowed  map[*trackedConn]struct{}
+	}
+
+	// trackedConn wraps a borrowed connection along with the stack of the
+	// goroutine which borrowed it. The remaining fields are guarded by the
+	// mutex of the leak detector.
+	trackedConn struct {
+		Conn
+		detector     *leakDetector
+		stack        []byte
+		borrowedAt   time.Time
+		releaseStack []byte
+		reported     bool
+		closed       bool
+	}
+)
+
+func newLeakDetector(threshold time.Duration, logger LeveledLogger, clock glock.Clock) *leakDetector {
+	return &leakDetector{
+		threshold: threshold,
+		logger:    logger,
+		clock:     clock,
+		borrowed:  map[*trackedConn]struct{}{},
+	}
+}
+
+// Close closes the underlying connection. A connection closed by the
+// borrower is no longer considered borrowed, as the borrower will then
+// discard it rather than release it.
+func (c *trackedConn) Close() error {
+	c.detector.mutex.Lock()
+	if _, ok := c.detector.borrowed[c]; ok {
+		delete(c.detector.borrowed, c)
+		c.closed = true
+	}
+	c.detector.mutex.Unlock()
+
+	return c.Conn.Close()
+}
+
+// Wrap a connection which is about to be handed to a borrower.
+func (d *leakDetector) track(conn Conn) Conn {
+	c := &trackedConn{
+		Conn:       conn,
+		detector:   d,
+		stack:      debug.Stack(),
+		borrowedAt: d.clock.Now(),
+	}
+
+	d.mutex.Lock()
+	d.borrowed[c] = struct{}{}
+	d.mutex.Unlock()
+
+	return c
+}
+
+// Unwrap a connection which is being released to the pool. The last flag
+// is false if the release is invalid and should be ignored. The closed flag
+// is true if the connection has already been closed by the borrower.
+func (d *leakDetector) untrack(conn Conn) (_ Conn, closed, ok bool) {
+	if conn == nil {
+		return nil, false, true
+	}
+
+	c, ok := conn.(*trackedConn)
+	if !ok || c.detector != d {
+		d.logger.Error("Ignoring release of a connection which was not borrowed from this pool", "stack", string(debug.Stack()))
+		return nil, false, false
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if c.releaseStack != nil {
+		d.logger.Error("Ignoring connection which was released more than once", "first_release", string(c.releaseStack), "stack", string(debug.Stack()))
+		return nil, false, false
+	}
+
+	delete(d.borrowed, c)
+	c.releaseStack = debug.Stack()
+
+	return c.Conn, c.closed, true
+}
+
+// Log each connection which has been borrowed for longer than the threshold.
+// Each connection is reported at most once.
+func (d *leakDetector) check() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for c := range d.borrowed {
+		held := d.clock.Since(c.borrowedAt)
+
+		if !c.reported && held >= d.threshold {
+			c.reported = true
+			d.logger.Error("Connection has been borrowed for too long without being released", "held", held, "stack", string(c.stack))
+		}
+	}
+}
+
+// Describe each connection which has been borrowed for longer than the
+// threshold.
+func (d *leakDetector) leaks() []LeakReport {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	reports := []LeakReport{}
+	for c := range d.borrowed {
+		if held := d.clock.Since(c.borrowedAt); held >= d.threshold {
+			reports = append(reports, LeakReport{Held: held, Stack: string(c.stack)})
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Held > reports[j].Held })
+	return reports
+}
+
+// Log each connection which is still borrowed.
+func (d *leakDetector) report() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for c := range d.borrowed {
+		d.logger.Error("Connection was not released before the pool was closed", "held", d.clock.Since(c.borrowedAt), "stack", string(c.stack))
+	}
+}
@@ -0,0 +1,177 @@
+package deepjoy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type LeakSuite struct{}
+
+func (s *LeakSuite) TestDoubleRelease(t sweet.T) {
+	var (
+		logger, messages = makeRecordingLogger()
+		pool             = makeLeakDetectingPool(logger, glock.NewMockClock())
+	)
+
+	defer pool.Close()
+
+	c, _ := pool.Borrow()
+	pool.Release(c)
+	pool.Release(c)
+
+	Eventually(messages).Should(Receive(ContainSubstring("released more than once")))
+
+	stats := pool.Stats()
+	Expect(stats.Idle).To(Equal(1))
+	Expect(stats.InUse).To(Equal(0))
+}
+
+func (s *LeakSuite) TestForeignRelease(t sweet.T) {
+	var (
+		logger, messages = makeRecordingLogger()
+		pool             = makeLeakDetectingPool(logger, glock.NewMockClock())
+		other            = makeLeakDetectingPool(NilLogger, glock.NewMockClock())
+	)
+
+	defer pool.Close()
+	defer other.Close()
+
+	c, _ := other.Borrow()
+	defer other.Release(c)
+
+	pool.Release(mocks.NewMockConn())
+	Eventually(messages).Should(Receive(ContainSubstring("not borrowed from this pool")))
+
+	pool.Release(c)
+	Eventually(messages).Should(Receive(ContainSubstring("not borrowed from this pool")))
+
+	Expect(pool.Stats().Idle).To(Equal(0))
+}
+
+func (s *LeakSuite) TestHeldTooLong(t sweet.T) {
+	var (
+		clock            = glock.NewMockClock()
+		logger, messages = makeRecordingLogger()
+		pool             = makeLeakDetectingPool(logger, clock)
+	)
+
+	c, _ := pool.Borrow()
+
+	clock.BlockingAdvance(time.Second * 30)
+	Consistently(messages).ShouldNot(Receive(ContainSubstring("without being released")))
+
+	// The borrow stack is included in the message
+	clock.BlockingAdvance(time.Second * 30)
+	Eventually(messages).Should(Receive(SatisfyAll(
+		ContainSubstring("without being released"),
+		ContainSubstring("leak_test.go"),
+	)))
+
+	leaks := leakReports(pool)
+	Expect(leaks).To(HaveLen(1))
+	Expect(leaks[0].Held).To(Equal(time.Minute))
+	Expect(leaks[0].Stack).To(ContainSubstring("leak_test.go"))
+
+	pool.Release(c)
+	pool.Close()
+}
+
+func (s *LeakSuite) TestOutstandingAtClose(t sweet.T) {
+	var (
+		logger, messages = makeRecordingLogger()
+		pool             = makeLeakDetectingPool(logger, glock.NewMockClock())
+	)
+
+	pool.Borrow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pool.CloseContext(ctx)
+	Eventually(messages).Should(Receive(ContainSubstring("not released before the pool was closed")))
+}
+
+func (s *LeakSuite) TestReleasedDuringClose(t sweet.T) {
+	var (
+		logger, messages = makeRecordingLogger()
+		pool             = makeLeakDetectingPool(logger, glock.NewMockClock())
+		result           = make(chan error)
+	)
+
+	c, _ := pool.Borrow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	go func() {
+		result <- pool.CloseContext(ctx)
+	}()
+
+	Consistently(result).ShouldNot(Receive())
+	pool.Release(c)
+	Eventually(result).Should(Receive(BeNil()))
+
+	// Borrows returned while the pool drains are not reported
+	Consistently(messages).ShouldNot(Receive())
+}
+
+func (s *LeakSuite) TestClosedByBorrower(t sweet.T) {
+	var (
+		logger, messages = makeRecordingLogger()
+		pool             = makeLeakDetectingPool(logger, glock.NewMockClock())
+	)
+
+	c, _ := pool.Borrow()
+	c.Close()
+	pool.Discard(c)
+
+	Expect(pool.Stats().Open).To(Equal(0))
+	pool.Close()
+	Consistently(messages).ShouldNot(Receive())
+}
+
+func makeLeakDetectingPool(logger Logger, clock glock.Clock) Pool {
+	return NewPool(
+		testDial,
+		2,
+		logger,
+		noopBreakerFunc,
+		clock,
+		WithLeakDetection(time.Minute),
+	)
+}
+
+func leakReports(p Pool) []LeakReport {
+	return p.(*pool).leaks.leaks()
+}
+
+// makeRecordingLogger creates a logger which sends each formatted message
+// to the returned channel. Messages are dropped if the channel is full.
+func makeRecordingLogger() (Logger, <-chan string) {
+	var (
+		logger   = mocks.NewMockLogger()
+		messages = make(chan string, 100)
+	)
+
+	logger.PrintfFunc.SetDefaultHook(func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		if strings.Contains(message, "Established a new connection") {
+			return
+		}
+
+		select {
+		case messages <- message:
+		default:
+		}
+	})
+
+	return logger, messages
+}
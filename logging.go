@@ -1,7 +1,11 @@
 package deepjoy
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/efritz/deepjoy/iface"
 )
@@ -10,12 +14,44 @@ type (
 	// Logger is an interface to the logger the client writes to.
 	Logger = iface.Logger
 
+	// LeveledLogger is an interface to a structured logger the client
+	// writes to.
+	LeveledLogger = iface.LeveledLogger
+
+	// LogLevel is the severity of a logged message.
+	LogLevel int
+
 	printLogger struct{}
 	nilLogger   struct{}
+
+	printfLogger struct {
+		logger Logger
+		level  LogLevel
+	}
+
+	slogLogger struct {
+		logger *slog.Logger
+	}
 )
 
-// NilLogger is a singleton silent logger.
-var NilLogger = NewNilLogger()
+const (
+	// LevelDebug is the level of messages about routine operation.
+	LevelDebug LogLevel = iota
+
+	// LevelInfo is the level of messages about changes in client state.
+	LevelInfo
+
+	// LevelWarn is the level of messages about recoverable failures.
+	LevelWarn
+
+	// LevelError is the level of messages about failures which may
+	// require intervention.
+	LevelError
+)
+
+// NilLogger is a singleton silent logger. It implements both Logger and
+// LeveledLogger.
+var NilLogger = &nilLogger{}
 
 // NewPrintLogger creates a logger that prints to stdout.
 func NewPrintLogger() Logger {
@@ -27,9 +63,124 @@ func NewNilLogger() Logger {
 	return &nilLogger{}
 }
 
+// NewPrintfLogger adapts a Printf logger to a leveled logger. Messages below
+// the given level are discarded. Each message is prefixed with its level and
+// followed by its fields (e.g. "WARN Could not close connection (error=EOF)").
+func NewPrintfLogger(logger Logger, level LogLevel) LeveledLogger {
+	return &printfLogger{logger: logger, level: level}
+}
+
+// NewSlogLogger adapts a log/slog logger to a leveled logger. Fields are
+// passed to the slog logger as attributes.
+func NewSlogLogger(logger *slog.Logger) LeveledLogger {
+	return &slogLogger{logger: logger}
+}
+
+// Convert a logger supplied by the user into a leveled logger. Loggers which
+// already implement LeveledLogger are used directly. Printf loggers discard
+// debug messages, which are logged for each command.
+func leveledLogger(logger Logger) LeveledLogger {
+	if logger == nil {
+		return NilLogger
+	}
+
+	if leveled, ok := logger.(LeveledLogger); ok {
+		return leveled
+	}
+
+	return NewPrintfLogger(logger, LevelInfo)
+}
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
 func (l *printLogger) Printf(format string, args ...interface{}) {
 	log.Printf(format, args...)
 }
 
 func (l *nilLogger) Printf(format string, args ...interface{}) {
 }
+
+func (l *nilLogger) Debug(msg string, fields ...interface{}) {
+}
+
+func (l *nilLogger) Info(msg string, fields ...interface{}) {
+}
+
+func (l *nilLogger) Warn(msg string, fields ...interface{}) {
+}
+
+func (l *nilLogger) Error(msg string, fields ...interface{}) {
+}
+
+func (l *printfLogger) Debug(msg string, fields ...interface{}) {
+	l.log(LevelDebug, msg, fields)
+}
+
+func (l *printfLogger) Info(msg string, fields ...interface{}) {
+	l.log(LevelInfo, msg, fields)
+}
+
+func (l *printfLogger) Warn(msg string, fields ...interface{}) {
+	l.log(LevelWarn, msg, fields)
+}
+
+func (l *printfLogger) Error(msg string, fields ...interface{}) {
+	l.log(LevelError, msg, fields)
+}
+
+func (l *printfLogger) log(level LogLevel, msg string, fields []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	l.logger.Printf("%s %s%s", level, msg, formatFields(fields))
+}
+
+func (l *slogLogger) Debug(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelDebug, msg, fields...)
+}
+
+func (l *slogLogger) Info(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelInfo, msg, fields...)
+}
+
+func (l *slogLogger) Warn(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelWarn, msg, fields...)
+}
+
+func (l *slogLogger) Error(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelError, msg, fields...)
+}
+
+// Format alternating keys and values as a parenthesized list of pairs. A
+// trailing key without a value is printed with a missing value.
+func formatFields(fields []interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, (len(fields)+1)/2)
+	for i := 0; i < len(fields); i += 2 {
+		var value interface{} = "!MISSING"
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+
+		pairs = append(pairs, fmt.Sprintf("%v=%v", fields[i], value))
+	}
+
+	return " (" + strings.Join(pairs, ", ") + ")"
+}
@@ -0,0 +1,126 @@
+package deepjoy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	. "github.com/efritz/go-mockgen/matchers"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type LoggingSuite struct{}
+
+func (s *LoggingSuite) TestPrintfLogger(t sweet.T) {
+	var (
+		printf = mocks.NewMockLogger()
+		logger = NewPrintfLogger(printf, LevelInfo)
+	)
+
+	logger.Debug("Received connection", "elapsed_ms", 3)
+	logger.Warn("Could not close connection", "error", io.EOF, "addr")
+	logger.Info("Resized pool")
+
+	Expect(printf.PrintfFunc).To(BeCalledN(2))
+	Expect(printf.PrintfFunc).To(BeCalledWith("%s %s%s", LevelWarn, "Could not close connection", " (error=EOF, addr=!MISSING)"))
+	Expect(printf.PrintfFunc).To(BeCalledWith("%s %s%s", LevelInfo, "Resized pool", ""))
+}
+
+func (s *LoggingSuite) TestLeveledLoggerPassthrough(t sweet.T) {
+	Expect(leveledLogger(nil)).To(BeIdenticalTo(NilLogger))
+	Expect(leveledLogger(NilLogger)).To(BeIdenticalTo(NilLogger))
+	Expect(leveledLogger(NewPrintLogger())).To(Equal(NewPrintfLogger(NewPrintLogger(), LevelInfo)))
+}
+
+func (s *LoggingSuite) TestSlogLogger(t sweet.T) {
+	var (
+		buffer  = &bytes.Buffer{}
+		handler = slog.NewTextHandler(buffer, &slog.HandlerOptions{Level: slog.LevelWarn})
+		logger  = NewSlogLogger(slog.New(handler))
+	)
+
+	logger.Debug("Received connection", "elapsed_ms", 3)
+	logger.Warn("Could not close connection", "error", io.EOF)
+
+	Expect(buffer.String()).NotTo(ContainSubstring("Received connection"))
+	Expect(buffer.String()).To(ContainSubstring(`level=WARN msg="Could not close connection" error=EOF`))
+}
+
+func (s *LoggingSuite) TestSampledLogger(t sweet.T) {
+	var (
+		inner  = mocks.NewMockLeveledLogger()
+		clock  = glock.NewMockClock()
+		logger = newSampledLogger(inner, 2, 5, time.Second, clock)
+	)
+
+	for i := 0; i < 12; i++ {
+		logger.Debug("Received connection")
+		logger.Warn("Could not close connection")
+	}
+
+	// Two messages, then the 7th and 12th
+	Expect(inner.DebugFunc).To(BeCalledN(4))
+	Expect(inner.WarnFunc).To(BeCalledN(12))
+
+	clock.Advance(time.Second)
+	logger.Debug("Received connection")
+	Expect(inner.DebugFunc).To(BeCalledN(5))
+}
+
+func (s *LoggingSuite) TestSampledPrintfLogger(t sweet.T) {
+	var (
+		printf = mocks.NewMockLogger()
+		clock  = glock.NewMockClock()
+		logger = newSampledLogger(NewPrintfLogger(printf, LevelDebug), 2, 5, time.Second, clock)
+	)
+
+	for i := 0; i < 12; i++ {
+		logger.Debug("Could not borrow connection")
+	}
+
+	Expect(printf.PrintfFunc).To(BeCalledN(4))
+}
+
+func (s *LoggingSuite) TestBorrowFailureLoggedAtDebug(t sweet.T) {
+	var (
+		logger = mocks.NewMockLeveledLogger()
+		c      = makeClient(mocks.NewMockPool(), nil)
+	)
+
+	c.logger = logger
+	c.borrowTimeout = new(time.Duration)
+
+	_, err := c.timedBorrow(context.Background())
+	Expect(err).To(Equal(ErrNoConnection))
+	Expect(logger.DebugFunc).To(BeCalledWith("Could not borrow connection", "elapsed_ms", BeNumerically(">=", 0), "error", ErrNoConnection))
+	Expect(logger.WarnFunc).NotTo(BeCalled())
+}
+
+func (s *LoggingSuite) TestSampledLoggerDisabled(t sweet.T) {
+	inner := mocks.NewMockLeveledLogger()
+	Expect(newSampledLogger(inner, 0, 0, time.Second, glock.NewMockClock())).To(BeIdenticalTo(inner))
+}
+
+func (s *LoggingSuite) TestBorrowLogsAtDebug(t sweet.T) {
+	var (
+		logger = mocks.NewMockLeveledLogger()
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		c      = makeClient(pool, nil)
+	)
+
+	c.logger = logger
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+	Expect(logger.DebugFunc).To(BeCalledOnce())
+	Expect(logger.DebugFunc.History()[0].Arg0).To(Equal("Received connection"))
+	Expect(logger.WarnFunc).NotTo(BeCalled())
+}
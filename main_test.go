@@ -16,5 +16,20 @@ func TestMain(m *testing.M) {
 
 		s.AddSuite(&PoolSuite{})
 		s.AddSuite(&ClientSuite{})
+		s.AddSuite(&ReplicaSuite{})
+		s.AddSuite(&LagSuite{})
+		s.AddSuite(&TLSSuite{})
+		s.AddSuite(&CredentialsSuite{})
+		s.AddSuite(&URLSuite{})
+		s.AddSuite(&DialerSuite{})
+		s.AddSuite(&LeakSuite{})
+		s.AddSuite(&SessionSuite{})
+		s.AddSuite(&HookSuite{})
+		s.AddSuite(&MetricsSuite{})
+		s.AddSuite(&TracingSuite{})
+		s.AddSuite(&LoggingSuite{})
+		s.AddSuite(&RedactSuite{})
+		s.AddSuite(&CommandLogSuite{})
+		s.AddSuite(&EventsSuite{})
 	})
 }
@@ -0,0 +1,231 @@
+package deepjoy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/efritz/glock"
+	"github.com/gomodule/redigo/redis"
+)
+
+type (
+	// Metrics receives measurements from a client. Every measurement is
+	// identified by one of the Metric name constants and a fixed set of
+	// labels. Implementations must be goroutine-safe. Durations are
+	// observed in seconds.
+	Metrics interface {
+		// IncCounter increments the named counter by one.
+		IncCounter(name string, labels MetricLabels)
+
+		// SetGauge sets the current value of the named gauge.
+		SetGauge(name string, labels MetricLabels, value float64)
+
+		// ObserveHistogram adds an observation to the named histogram.
+		ObserveHistogram(name string, labels MetricLabels, value float64)
+	}
+
+	// MetricLabels identifies the series to which a measurement belongs.
+	// Labels which do not apply to a measurement are empty (e.g. pool
+	// gauges have no command or outcome).
+	MetricLabels struct {
+		// Command is the upper-case name of the command, or PIPELINE for
+		// a pipeline of commands.
+		Command string
+
+		// Role is either RolePrimary or RoleReplica.
+		Role string
+
+		// Address is the address of the server.
+		Address string
+
+		// Outcome is one of the Outcome constants.
+		Outcome string
+	}
+
+	clientMetrics struct {
+		NoopClientHook
+		metrics  Metrics
+		pool     Pool
+		role     string
+		addr     string
+		clock    glock.Clock
+		halt     chan struct{}
+		haltOnce sync.Once
+		wg       sync.WaitGroup
+	}
+)
+
+const (
+	// MetricCommands counts commands by command, role, address, and outcome.
+	MetricCommands = "commands_total"
+
+	// MetricCommandDuration is a histogram of command latency, including the
+	// time spent borrowing a connection.
+	MetricCommandDuration = "command_duration_seconds"
+
+	// MetricBorrowWait is a histogram of the time spent borrowing a
+	// connection from the pool, by role, address, and outcome.
+	MetricBorrowWait = "borrow_wait_seconds"
+
+	// MetricDials counts attempts to dial a new connection by role,
+	// address, and outcome.
+	MetricDials = "dials_total"
+
+	// MetricDialDuration is a histogram of the time spent dialing (and
+	// initializing) a new connection.
+	MetricDialDuration = "dial_duration_seconds"
+
+	// MetricPoolCapacity is a gauge of the capacity of a pool.
+	MetricPoolCapacity = "pool_capacity"
+
+	// MetricPoolOpen is a gauge of the open connections in a pool.
+	MetricPoolOpen = "pool_open_connections"
+
+	// MetricPoolIdle is a gauge of the idle connections in a pool.
+	MetricPoolIdle = "pool_idle_connections"
+
+	// MetricPoolInUse is a gauge of the borrowed connections in a pool.
+	MetricPoolInUse = "pool_in_use_connections"
+)
+
+const (
+	// RolePrimary labels measurements of the primary server.
+	RolePrimary = "primary"
+
+	// RoleReplica labels measurements of a read replica.
+	RoleReplica = "replica"
+)
+
+const (
+	// OutcomeSuccess labels operations which succeeded.
+	OutcomeSuccess = "success"
+
+	// OutcomeRedisError labels commands to which the server replied with
+	// an error.
+	OutcomeRedisError = "redis_error"
+
+	// OutcomeNoConnection labels operations which failed because a
+	// connection could not be borrowed from the pool.
+	OutcomeNoConnection = "no_connection"
+
+	// OutcomeCanceled labels operations which were abandoned because
+	// their context was canceled or its deadline elapsed.
+	OutcomeCanceled = "canceled"
+
+	// OutcomeError labels operations which failed for any other reason
+	// (e.g. a network error).
+	OutcomeError = "error"
+)
+
+// pipelineCommandLabel is the command label of a pipeline.
+const pipelineCommandLabel = "PIPELINE"
+
+// Create a hook which reports to the given metrics sink. The pool gauges
+// are published once the pool is created (see start), as the hook is needed
+// to construct its dialer.
+func newClientMetrics(metrics Metrics, role, addr string) *clientMetrics {
+	return &clientMetrics{
+		metrics: metrics,
+		role:    role,
+		addr:    addr,
+		halt:    make(chan struct{}),
+	}
+}
+
+// Publish the gauges of the given pool on the given interval until the
+// client is closed. The gauges are not published after each command, as
+// reading the state of the pool contends on its mutex.
+func (m *clientMetrics) start(pool Pool, interval time.Duration, clock glock.Clock) {
+	m.pool = pool
+	m.clock = clock
+
+	m.wg.Add(1)
+	go m.collect(interval)
+}
+
+func (m *clientMetrics) collect(interval time.Duration) {
+	defer m.wg.Done()
+
+	for {
+		m.observePool()
+
+		select {
+		case <-m.clock.After(interval):
+		case <-m.halt:
+			return
+		}
+	}
+}
+
+func (m *clientMetrics) stop() {
+	m.haltOnce.Do(func() { close(m.halt) })
+	m.wg.Wait()
+}
+
+func (m *clientMetrics) AfterDo(ctx context.Context, event *DoEvent) {
+	m.observeCommand(strings.ToUpper(event.Command), event.Err, event.Duration.Seconds())
+}
+
+func (m *clientMetrics) AfterPipeline(ctx context.Context, event *PipelineEvent) {
+	m.observeCommand(pipelineCommandLabel, event.Err, event.Duration.Seconds())
+}
+
+func (m *clientMetrics) AfterDial(ctx context.Context, event *DialEvent) {
+	labels := m.labels("", event.Err)
+	m.metrics.IncCounter(MetricDials, labels)
+	m.metrics.ObserveHistogram(MetricDialDuration, labels, event.Duration.Seconds())
+}
+
+func (m *clientMetrics) AfterBorrow(ctx context.Context, event *BorrowEvent) {
+	m.metrics.ObserveHistogram(MetricBorrowWait, m.labels("", event.Err), event.Duration.Seconds())
+}
+
+func (m *clientMetrics) observeCommand(command string, err error, seconds float64) {
+	labels := m.labels(command, err)
+	m.metrics.IncCounter(MetricCommands, labels)
+	m.metrics.ObserveHistogram(MetricCommandDuration, labels, seconds)
+}
+
+func (m *clientMetrics) observePool() {
+	var (
+		stats  = m.pool.Stats()
+		labels = MetricLabels{Role: m.role, Address: m.addr}
+	)
+
+	m.metrics.SetGauge(MetricPoolCapacity, labels, float64(stats.Capacity))
+	m.metrics.SetGauge(MetricPoolOpen, labels, float64(stats.Open))
+	m.metrics.SetGauge(MetricPoolIdle, labels, float64(stats.Idle))
+	m.metrics.SetGauge(MetricPoolInUse, labels, float64(stats.InUse))
+}
+
+func (m *clientMetrics) labels(command string, err error) MetricLabels {
+	return MetricLabels{
+		Command: command,
+		Role:    m.role,
+		Address: m.addr,
+		Outcome: outcome(err),
+	}
+}
+
+// Determine the outcome label of an operation from its error.
+func outcome(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+
+	if _, ok := err.(redis.Error); ok {
+		return OutcomeRedisError
+	}
+
+	if err == ErrNoConnection || err == ErrPoolExhausted || err == ErrPoolClosed {
+		return OutcomeNoConnection
+	}
+
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return OutcomeCanceled
+	}
+
+	return OutcomeError
+}
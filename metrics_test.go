@@ -0,0 +1,250 @@
+package deepjoy
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/gomodule/redigo/redis"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type MetricsSuite struct{}
+
+// expvarRuns distinguishes the variables published by each run of the expvar
+// test, as expvar panics when a name is published twice.
+var expvarRuns int32
+
+type recordingMetrics struct {
+	mutex        sync.Mutex
+	counters     map[string]map[MetricLabels]int
+	gauges       map[string]map[MetricLabels]float64
+	observations map[string]map[MetricLabels][]float64
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		counters:     map[string]map[MetricLabels]int{},
+		gauges:       map[string]map[MetricLabels]float64{},
+		observations: map[string]map[MetricLabels][]float64{},
+	}
+}
+
+func (m *recordingMetrics) IncCounter(name string, labels MetricLabels) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.counters[name]; !ok {
+		m.counters[name] = map[MetricLabels]int{}
+	}
+
+	m.counters[name][labels]++
+}
+
+func (m *recordingMetrics) SetGauge(name string, labels MetricLabels, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.gauges[name]; !ok {
+		m.gauges[name] = map[MetricLabels]float64{}
+	}
+
+	m.gauges[name][labels] = value
+}
+
+func (m *recordingMetrics) ObserveHistogram(name string, labels MetricLabels, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.observations[name]; !ok {
+		m.observations[name] = map[MetricLabels][]float64{}
+	}
+
+	m.observations[name][labels] = append(m.observations[name][labels], value)
+}
+
+func (m *recordingMetrics) counter(name string, labels MetricLabels) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.counters[name][labels]
+}
+
+func (m *recordingMetrics) gauge(name string, labels MetricLabels) float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.gauges[name][labels]
+}
+
+func (m *recordingMetrics) observed(name string, labels MetricLabels) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.observations[name][labels])
+}
+
+func makeMetricsClient(metrics Metrics, conn Conn, configs ...ConfigFunc) Client {
+	return NewClient("localhost:6379", append([]ConfigFunc{
+		WithLogger(NilLogger),
+		WithPoolCapacity(2),
+		WithMetrics(metrics),
+		WithDialerFactory(func(addrs []string) DialFunc {
+			return func() (Conn, error) { return conn, nil }
+		}),
+	}, configs...)...)
+}
+
+func (s *MetricsSuite) TestCommandMetrics(t sweet.T) {
+	var (
+		metrics = newRecordingMetrics()
+		conn    = mocks.NewMockConn()
+		client  = makeMetricsClient(metrics, conn)
+	)
+
+	defer client.Close()
+	conn.DoFunc.SetDefaultReturn("bar", nil)
+
+	_, err := client.Do("get", "foo")
+	Expect(err).To(BeNil())
+
+	labels := MetricLabels{Command: "GET", Role: RolePrimary, Address: "localhost:6379", Outcome: OutcomeSuccess}
+	Expect(metrics.counter(MetricCommands, labels)).To(Equal(1))
+	Expect(metrics.observed(MetricCommandDuration, labels)).To(Equal(1))
+
+	labels = MetricLabels{Role: RolePrimary, Address: "localhost:6379", Outcome: OutcomeSuccess}
+	Expect(metrics.counter(MetricDials, labels)).To(Equal(1))
+	Expect(metrics.observed(MetricDialDuration, labels)).To(Equal(1))
+	Expect(metrics.observed(MetricBorrowWait, labels)).To(Equal(1))
+}
+
+func (s *MetricsSuite) TestPoolMetrics(t sweet.T) {
+	var (
+		metrics = newRecordingMetrics()
+		conn    = mocks.NewMockConn()
+		client  = makeMetricsClient(metrics, conn, WithMetricsInterval(time.Millisecond*10))
+		labels  = MetricLabels{Role: RolePrimary, Address: "localhost:6379"}
+	)
+
+	defer client.Close()
+	conn.DoFunc.SetDefaultReturn("bar", nil)
+
+	// Published before any command is run
+	Eventually(func() float64 { return metrics.gauge(MetricPoolCapacity, labels) }).Should(Equal(2.0))
+
+	_, err := client.Do("get", "foo")
+	Expect(err).To(BeNil())
+
+	Eventually(func() float64 { return metrics.gauge(MetricPoolOpen, labels) }).Should(Equal(1.0))
+	Eventually(func() float64 { return metrics.gauge(MetricPoolIdle, labels) }).Should(Equal(1.0))
+	Expect(metrics.gauge(MetricPoolInUse, labels)).To(Equal(0.0))
+}
+
+func (s *MetricsSuite) TestCommandMetricsOutcome(t sweet.T) {
+	var (
+		metrics = newRecordingMetrics()
+		conn    = mocks.NewMockConn()
+		client  = makeMetricsClient(metrics, conn)
+	)
+
+	defer client.Close()
+	conn.DoFunc.PushReturn(nil, redis.Error("ERR wrong type"))
+
+	_, err := client.Do("INCR", "foo")
+	Expect(err).To(Equal(redis.Error("ERR wrong type")))
+
+	labels := MetricLabels{Command: "INCR", Role: RolePrimary, Address: "localhost:6379", Outcome: OutcomeRedisError}
+	Expect(metrics.counter(MetricCommands, labels)).To(Equal(1))
+}
+
+func (s *MetricsSuite) TestPipelineMetrics(t sweet.T) {
+	var (
+		metrics = newRecordingMetrics()
+		conn    = mocks.NewMockConn()
+		client  = makeMetricsClient(metrics, conn)
+	)
+
+	defer client.Close()
+	conn.DoFunc.SetDefaultReturn([]interface{}{"OK"}, nil)
+
+	pipeline := client.Pipeline()
+	pipeline.Add("SET", "foo", "bar")
+	_, err := pipeline.Run()
+	Expect(err).To(BeNil())
+
+	labels := MetricLabels{Command: "PIPELINE", Role: RolePrimary, Address: "localhost:6379", Outcome: OutcomeSuccess}
+	Expect(metrics.counter(MetricCommands, labels)).To(Equal(1))
+}
+
+func (s *MetricsSuite) TestReplicaMetrics(t sweet.T) {
+	var (
+		metrics = newRecordingMetrics()
+		conn    = mocks.NewMockConn()
+		client  = makeMetricsClient(metrics, conn, WithReadReplicaAddrs("replica:6379"))
+	)
+
+	defer client.Close()
+	conn.DoFunc.SetDefaultReturn("bar", nil)
+
+	_, err := client.ReadReplica().Do("GET", "foo")
+	Expect(err).To(BeNil())
+
+	labels := MetricLabels{Command: "GET", Role: RoleReplica, Address: "replica:6379", Outcome: OutcomeSuccess}
+	Expect(metrics.counter(MetricCommands, labels)).To(Equal(1))
+
+	labels.Role, labels.Address = RolePrimary, "localhost:6379"
+	Expect(metrics.counter(MetricCommands, labels)).To(Equal(0))
+}
+
+func (s *MetricsSuite) TestNoMetrics(t sweet.T) {
+	c := NewClient("localhost:6379", WithLogger(NilLogger))
+	defer c.Close()
+
+	Expect(c.(*client).hooks).To(BeEmpty())
+}
+
+func (s *MetricsSuite) TestOutcome(t sweet.T) {
+	Expect(outcome(nil)).To(Equal(OutcomeSuccess))
+	Expect(outcome(redis.Error("ERR"))).To(Equal(OutcomeRedisError))
+	Expect(outcome(ErrNoConnection)).To(Equal(OutcomeNoConnection))
+	Expect(outcome(ErrPoolExhausted)).To(Equal(OutcomeNoConnection))
+	Expect(outcome(connErr{redis.ErrNil})).To(Equal(OutcomeError))
+}
+
+func (s *MetricsSuite) TestExpvarMetrics(t sweet.T) {
+	var (
+		name    = fmt.Sprintf("deepjoy-test-metrics-%d", atomic.AddInt32(&expvarRuns, 1))
+		metrics = NewExpvarMetrics(name)
+		labels  = MetricLabels{Command: "GET", Role: RolePrimary, Address: "localhost:6379", Outcome: OutcomeSuccess}
+		gauge   = MetricLabels{Role: RolePrimary, Address: "localhost:6379"}
+	)
+
+	metrics.IncCounter(MetricCommands, labels)
+	metrics.IncCounter(MetricCommands, labels)
+	metrics.SetGauge(MetricPoolOpen, gauge, 3)
+	metrics.ObserveHistogram(MetricCommandDuration, labels, 0.002)
+	metrics.ObserveHistogram(MetricCommandDuration, labels, 0.3)
+
+	var published map[string]map[string]json.RawMessage
+	Expect(json.Unmarshal([]byte(expvar.Get(name).String()), &published)).To(BeNil())
+
+	var (
+		key      = `command="GET",role="primary",address="localhost:6379",outcome="success"`
+		gaugeKey = `role="primary",address="localhost:6379"`
+	)
+
+	Expect(string(published[MetricCommands][key])).To(Equal("2"))
+	Expect(string(published[MetricPoolOpen][gaugeKey])).To(Equal("3"))
+
+	var histogram expvarHistogramJSON
+	Expect(json.Unmarshal(published[MetricCommandDuration][key], &histogram)).To(BeNil())
+	Expect(histogram.Count).To(Equal(uint64(2)))
+	Expect(histogram.Sum).To(BeNumerically("~", 0.302))
+	Expect(histogram.Buckets).To(HaveLen(len(DefaultLatencyBuckets)))
+	Expect(histogram.Buckets[2]).To(Equal(expvarBucketJSON{UpperBound: 0.0025, Count: 1}))
+	Expect(histogram.Buckets[8]).To(Equal(expvarBucketJSON{UpperBound: 0.25, Count: 1}))
+	Expect(histogram.Buckets[9]).To(Equal(expvarBucketJSON{UpperBound: 0.5, Count: 2}))
+}
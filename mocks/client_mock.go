@@ -1,12 +1,16 @@
 // Code generated by github.com/efritz/go-mockgen; DO NOT EDIT.
 // This file was generated by robots at
-// 2018-10-23T15:57:11-05:00
+// 2026-10-18T17:13:14-05:00
 // using the command
 // $ go-mockgen -f github.com/efritz/deepjoy/iface
 
 package mocks
 
-import iface "github.com/efritz/deepjoy/iface"
+import (
+	"context"
+	iface "github.com/efritz/deepjoy/iface"
+	"time"
+)
 
 // MockClient is a mock impelementation of the Client interface (from the
 // package github.com/efritz/deepjoy/iface) used for unit testing.
@@ -14,15 +18,39 @@ type MockClient struct {
 	// CloseFunc is an instance of a mock function object controlling the
 	// behavior of the method Close.
 	CloseFunc *ClientCloseFunc
+	// CloseContextFunc is an instance of a mock function object controlling
+	// the behavior of the method CloseContext.
+	CloseContextFunc *ClientCloseContextFunc
 	// DoFunc is an instance of a mock function object controlling the
 	// behavior of the method Do.
 	DoFunc *ClientDoFunc
+	// DoContextFunc is an instance of a mock function object controlling
+	// the behavior of the method DoContext.
+	DoContextFunc *ClientDoContextFunc
+	// DoWithTokenFunc is an instance of a mock function object controlling
+	// the behavior of the method DoWithToken.
+	DoWithTokenFunc *ClientDoWithTokenFunc
+	// EventsFunc is an instance of a mock function object controlling the
+	// behavior of the method Events.
+	EventsFunc *ClientEventsFunc
 	// PipelineFunc is an instance of a mock function object controlling the
 	// behavior of the method Pipeline.
 	PipelineFunc *ClientPipelineFunc
 	// ReadReplicaFunc is an instance of a mock function object controlling
 	// the behavior of the method ReadReplica.
 	ReadReplicaFunc *ClientReadReplicaFunc
+	// ReadReplicaAfterFunc is an instance of a mock function object
+	// controlling the behavior of the method ReadReplicaAfter.
+	ReadReplicaAfterFunc *ClientReadReplicaAfterFunc
+	// ReadReplicaWithinFunc is an instance of a mock function object
+	// controlling the behavior of the method ReadReplicaWithin.
+	ReadReplicaWithinFunc *ClientReadReplicaWithinFunc
+	// StateFunc is an instance of a mock function object controlling the
+	// behavior of the method State.
+	StateFunc *ClientStateFunc
+	// StatsFunc is an instance of a mock function object controlling the
+	// behavior of the method Stats.
+	StatsFunc *ClientStatsFunc
 }
 
 // NewMockClient creates a new mock of the Client interface. All methods
@@ -34,11 +62,31 @@ func NewMockClient() *MockClient {
 				return
 			},
 		},
+		CloseContextFunc: &ClientCloseContextFunc{
+			defaultHook: func(context.Context) error {
+				return nil
+			},
+		},
 		DoFunc: &ClientDoFunc{
 			defaultHook: func(string, ...interface{}) (interface{}, error) {
 				return nil, nil
 			},
 		},
+		DoContextFunc: &ClientDoContextFunc{
+			defaultHook: func(context.Context, string, ...interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		},
+		DoWithTokenFunc: &ClientDoWithTokenFunc{
+			defaultHook: func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error) {
+				return nil, iface.ConsistencyToken{}, nil
+			},
+		},
+		EventsFunc: &ClientEventsFunc{
+			defaultHook: func() iface.EventSubscription {
+				return nil
+			},
+		},
 		PipelineFunc: &ClientPipelineFunc{
 			defaultHook: func() iface.Pipeline {
 				return nil
@@ -49,6 +97,26 @@ func NewMockClient() *MockClient {
 				return nil
 			},
 		},
+		ReadReplicaAfterFunc: &ClientReadReplicaAfterFunc{
+			defaultHook: func(iface.ConsistencyToken) iface.Client {
+				return nil
+			},
+		},
+		ReadReplicaWithinFunc: &ClientReadReplicaWithinFunc{
+			defaultHook: func(time.Duration) iface.Client {
+				return nil
+			},
+		},
+		StateFunc: &ClientStateFunc{
+			defaultHook: func() iface.ClientState {
+				return iface.ClientState{}
+			},
+		},
+		StatsFunc: &ClientStatsFunc{
+			defaultHook: func() iface.ClientStats {
+				return iface.ClientStats{}
+			},
+		},
 	}
 }
 
@@ -59,15 +127,39 @@ func NewMockClientFrom(i iface.Client) *MockClient {
 		CloseFunc: &ClientCloseFunc{
 			defaultHook: i.Close,
 		},
+		CloseContextFunc: &ClientCloseContextFunc{
+			defaultHook: i.CloseContext,
+		},
 		DoFunc: &ClientDoFunc{
 			defaultHook: i.Do,
 		},
+		DoContextFunc: &ClientDoContextFunc{
+			defaultHook: i.DoContext,
+		},
+		DoWithTokenFunc: &ClientDoWithTokenFunc{
+			defaultHook: i.DoWithToken,
+		},
+		EventsFunc: &ClientEventsFunc{
+			defaultHook: i.Events,
+		},
 		PipelineFunc: &ClientPipelineFunc{
 			defaultHook: i.Pipeline,
 		},
 		ReadReplicaFunc: &ClientReadReplicaFunc{
 			defaultHook: i.ReadReplica,
 		},
+		ReadReplicaAfterFunc: &ClientReadReplicaAfterFunc{
+			defaultHook: i.ReadReplicaAfter,
+		},
+		ReadReplicaWithinFunc: &ClientReadReplicaWithinFunc{
+			defaultHook: i.ReadReplicaWithin,
+		},
+		StateFunc: &ClientStateFunc{
+			defaultHook: i.State,
+		},
+		StatsFunc: &ClientStatsFunc{
+			defaultHook: i.Stats,
+		},
 	}
 }
 
@@ -149,6 +241,91 @@ func (c ClientCloseFuncCall) Results() []interface{} {
 	return []interface{}{}
 }
 
+// ClientCloseContextFunc describes the behavior when the CloseContext
+// method of the parent MockClient instance is invoked.
+type ClientCloseContextFunc struct {
+	defaultHook func(context.Context) error
+	hooks       []func(context.Context) error
+	history     []ClientCloseContextFuncCall
+}
+
+// CloseContext delegates to the next hook function in the queue and stores
+// the parameter and result values of this invocation.
+func (m *MockClient) CloseContext(v0 context.Context) error {
+	r0 := m.CloseContextFunc.nextHook()(v0)
+	m.CloseContextFunc.history = append(m.CloseContextFunc.history, ClientCloseContextFuncCall{v0, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the CloseContext method
+// of the parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientCloseContextFunc) SetDefaultHook(hook func(context.Context) error) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// CloseContext method of the parent MockClient instance inovkes the hook at
+// the front of the queue and discards it. After the queue is empty, the
+// default hook function is invoked for any future action.
+func (f *ClientCloseContextFunc) PushHook(hook func(context.Context) error) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientCloseContextFunc) SetDefaultReturn(r0 error) {
+	f.SetDefaultHook(func(context.Context) error {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientCloseContextFunc) PushReturn(r0 error) {
+	f.PushHook(func(context.Context) error {
+		return r0
+	})
+}
+
+func (f *ClientCloseContextFunc) nextHook() func(context.Context) error {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientCloseContextFuncCall objects
+// describing the invocations of this function.
+func (f *ClientCloseContextFunc) History() []ClientCloseContextFuncCall {
+	return f.history
+}
+
+// ClientCloseContextFuncCall is an object that describes an invocation of
+// method CloseContext on an instance of MockClient.
+type ClientCloseContextFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientCloseContextFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientCloseContextFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
 // ClientDoFunc describes the behavior when the Do method of the parent
 // MockClient instance is invoked.
 type ClientDoFunc struct {
@@ -242,6 +419,280 @@ func (c ClientDoFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// ClientDoContextFunc describes the behavior when the DoContext method of
+// the parent MockClient instance is invoked.
+type ClientDoContextFunc struct {
+	defaultHook func(context.Context, string, ...interface{}) (interface{}, error)
+	hooks       []func(context.Context, string, ...interface{}) (interface{}, error)
+	history     []ClientDoContextFuncCall
+}
+
+// DoContext delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockClient) DoContext(v0 context.Context, v1 string, v2 ...interface{}) (interface{}, error) {
+	r0, r1 := m.DoContextFunc.nextHook()(v0, v1, v2...)
+	m.DoContextFunc.history = append(m.DoContextFunc.history, ClientDoContextFuncCall{v0, v1, v2, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the DoContext method of
+// the parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientDoContextFunc) SetDefaultHook(hook func(context.Context, string, ...interface{}) (interface{}, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// DoContext method of the parent MockClient instance inovkes the hook at
+// the front of the queue and discards it. After the queue is empty, the
+// default hook function is invoked for any future action.
+func (f *ClientDoContextFunc) PushHook(hook func(context.Context, string, ...interface{}) (interface{}, error)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientDoContextFunc) SetDefaultReturn(r0 interface{}, r1 error) {
+	f.SetDefaultHook(func(context.Context, string, ...interface{}) (interface{}, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientDoContextFunc) PushReturn(r0 interface{}, r1 error) {
+	f.PushHook(func(context.Context, string, ...interface{}) (interface{}, error) {
+		return r0, r1
+	})
+}
+
+func (f *ClientDoContextFunc) nextHook() func(context.Context, string, ...interface{}) (interface{}, error) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientDoContextFuncCall objects describing
+// the invocations of this function.
+func (f *ClientDoContextFunc) History() []ClientDoContextFuncCall {
+	return f.history
+}
+
+// ClientDoContextFuncCall is an object that describes an invocation of
+// method DoContext on an instance of MockClient.
+type ClientDoContextFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 string
+	// Arg2 is a slice containing the values of the variadic arguments
+	// passed to this method invocation.
+	Arg2 []interface{}
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 interface{}
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation. The variadic slice argument is flattened in this array such
+// that one positional argument and three variadic arguments would result in
+// a slice of four, not two.
+func (c ClientDoContextFuncCall) Args() []interface{} {
+	return append([]interface{}{c.Arg0, c.Arg1}, c.Arg2...)
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientDoContextFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
+// ClientDoWithTokenFunc describes the behavior when the DoWithToken method
+// of the parent MockClient instance is invoked.
+type ClientDoWithTokenFunc struct {
+	defaultHook func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error)
+	hooks       []func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error)
+	history     []ClientDoWithTokenFuncCall
+}
+
+// DoWithToken delegates to the next hook function in the queue and stores
+// the parameter and result values of this invocation.
+func (m *MockClient) DoWithToken(v0 string, v1 ...interface{}) (interface{}, iface.ConsistencyToken, error) {
+	r0, r1, r2 := m.DoWithTokenFunc.nextHook()(v0, v1...)
+	m.DoWithTokenFunc.history = append(m.DoWithTokenFunc.history, ClientDoWithTokenFuncCall{v0, v1, r0, r1, r2})
+	return r0, r1, r2
+}
+
+// SetDefaultHook sets function that is called when the DoWithToken method
+// of the parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientDoWithTokenFunc) SetDefaultHook(hook func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// DoWithToken method of the parent MockClient instance inovkes the hook at
+// the front of the queue and discards it. After the queue is empty, the
+// default hook function is invoked for any future action.
+func (f *ClientDoWithTokenFunc) PushHook(hook func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientDoWithTokenFunc) SetDefaultReturn(r0 interface{}, r1 iface.ConsistencyToken, r2 error) {
+	f.SetDefaultHook(func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error) {
+		return r0, r1, r2
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientDoWithTokenFunc) PushReturn(r0 interface{}, r1 iface.ConsistencyToken, r2 error) {
+	f.PushHook(func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error) {
+		return r0, r1, r2
+	})
+}
+
+func (f *ClientDoWithTokenFunc) nextHook() func(string, ...interface{}) (interface{}, iface.ConsistencyToken, error) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientDoWithTokenFuncCall objects
+// describing the invocations of this function.
+func (f *ClientDoWithTokenFunc) History() []ClientDoWithTokenFuncCall {
+	return f.history
+}
+
+// ClientDoWithTokenFuncCall is an object that describes an invocation of
+// method DoWithToken on an instance of MockClient.
+type ClientDoWithTokenFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 string
+	// Arg1 is a slice containing the values of the variadic arguments
+	// passed to this method invocation.
+	Arg1 []interface{}
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 interface{}
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 iface.ConsistencyToken
+	// Result2 is the value of the 3rd result returned from this method
+	// invocation.
+	Result2 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation. The variadic slice argument is flattened in this array such
+// that one positional argument and three variadic arguments would result in
+// a slice of four, not two.
+func (c ClientDoWithTokenFuncCall) Args() []interface{} {
+	return append([]interface{}{c.Arg0}, c.Arg1...)
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientDoWithTokenFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1, c.Result2}
+}
+
+// ClientEventsFunc describes the behavior when the Events method of the
+// parent MockClient instance is invoked.
+type ClientEventsFunc struct {
+	defaultHook func() iface.EventSubscription
+	hooks       []func() iface.EventSubscription
+	history     []ClientEventsFuncCall
+}
+
+// Events delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockClient) Events() iface.EventSubscription {
+	r0 := m.EventsFunc.nextHook()()
+	m.EventsFunc.history = append(m.EventsFunc.history, ClientEventsFuncCall{r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the Events method of the
+// parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientEventsFunc) SetDefaultHook(hook func() iface.EventSubscription) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// Events method of the parent MockClient instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *ClientEventsFunc) PushHook(hook func() iface.EventSubscription) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientEventsFunc) SetDefaultReturn(r0 iface.EventSubscription) {
+	f.SetDefaultHook(func() iface.EventSubscription {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientEventsFunc) PushReturn(r0 iface.EventSubscription) {
+	f.PushHook(func() iface.EventSubscription {
+		return r0
+	})
+}
+
+func (f *ClientEventsFunc) nextHook() func() iface.EventSubscription {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientEventsFuncCall objects describing the
+// invocations of this function.
+func (f *ClientEventsFunc) History() []ClientEventsFuncCall {
+	return f.history
+}
+
+// ClientEventsFuncCall is an object that describes an invocation of method
+// Events on an instance of MockClient.
+type ClientEventsFuncCall struct {
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.EventSubscription
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientEventsFuncCall) Args() []interface{} {
+	return []interface{}{}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientEventsFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
 // ClientPipelineFunc describes the behavior when the Pipeline method of the
 // parent MockClient instance is invoked.
 type ClientPipelineFunc struct {
@@ -405,3 +856,339 @@ func (c ClientReadReplicaFuncCall) Args() []interface{} {
 func (c ClientReadReplicaFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0}
 }
+
+// ClientReadReplicaAfterFunc describes the behavior when the
+// ReadReplicaAfter method of the parent MockClient instance is invoked.
+type ClientReadReplicaAfterFunc struct {
+	defaultHook func(iface.ConsistencyToken) iface.Client
+	hooks       []func(iface.ConsistencyToken) iface.Client
+	history     []ClientReadReplicaAfterFuncCall
+}
+
+// ReadReplicaAfter delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockClient) ReadReplicaAfter(v0 iface.ConsistencyToken) iface.Client {
+	r0 := m.ReadReplicaAfterFunc.nextHook()(v0)
+	m.ReadReplicaAfterFunc.history = append(m.ReadReplicaAfterFunc.history, ClientReadReplicaAfterFuncCall{v0, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the ReadReplicaAfter
+// method of the parent MockClient instance is invoked and the hook queue is
+// empty.
+func (f *ClientReadReplicaAfterFunc) SetDefaultHook(hook func(iface.ConsistencyToken) iface.Client) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// ReadReplicaAfter method of the parent MockClient instance inovkes the
+// hook at the front of the queue and discards it. After the queue is empty,
+// the default hook function is invoked for any future action.
+func (f *ClientReadReplicaAfterFunc) PushHook(hook func(iface.ConsistencyToken) iface.Client) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientReadReplicaAfterFunc) SetDefaultReturn(r0 iface.Client) {
+	f.SetDefaultHook(func(iface.ConsistencyToken) iface.Client {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientReadReplicaAfterFunc) PushReturn(r0 iface.Client) {
+	f.PushHook(func(iface.ConsistencyToken) iface.Client {
+		return r0
+	})
+}
+
+func (f *ClientReadReplicaAfterFunc) nextHook() func(iface.ConsistencyToken) iface.Client {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientReadReplicaAfterFuncCall objects
+// describing the invocations of this function.
+func (f *ClientReadReplicaAfterFunc) History() []ClientReadReplicaAfterFuncCall {
+	return f.history
+}
+
+// ClientReadReplicaAfterFuncCall is an object that describes an invocation
+// of method ReadReplicaAfter on an instance of MockClient.
+type ClientReadReplicaAfterFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 iface.ConsistencyToken
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.Client
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientReadReplicaAfterFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientReadReplicaAfterFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
+// ClientReadReplicaWithinFunc describes the behavior when the
+// ReadReplicaWithin method of the parent MockClient instance is invoked.
+type ClientReadReplicaWithinFunc struct {
+	defaultHook func(time.Duration) iface.Client
+	hooks       []func(time.Duration) iface.Client
+	history     []ClientReadReplicaWithinFuncCall
+}
+
+// ReadReplicaWithin delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockClient) ReadReplicaWithin(v0 time.Duration) iface.Client {
+	r0 := m.ReadReplicaWithinFunc.nextHook()(v0)
+	m.ReadReplicaWithinFunc.history = append(m.ReadReplicaWithinFunc.history, ClientReadReplicaWithinFuncCall{v0, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the ReadReplicaWithin
+// method of the parent MockClient instance is invoked and the hook queue is
+// empty.
+func (f *ClientReadReplicaWithinFunc) SetDefaultHook(hook func(time.Duration) iface.Client) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// ReadReplicaWithin method of the parent MockClient instance inovkes the
+// hook at the front of the queue and discards it. After the queue is empty,
+// the default hook function is invoked for any future action.
+func (f *ClientReadReplicaWithinFunc) PushHook(hook func(time.Duration) iface.Client) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientReadReplicaWithinFunc) SetDefaultReturn(r0 iface.Client) {
+	f.SetDefaultHook(func(time.Duration) iface.Client {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientReadReplicaWithinFunc) PushReturn(r0 iface.Client) {
+	f.PushHook(func(time.Duration) iface.Client {
+		return r0
+	})
+}
+
+func (f *ClientReadReplicaWithinFunc) nextHook() func(time.Duration) iface.Client {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientReadReplicaWithinFuncCall objects
+// describing the invocations of this function.
+func (f *ClientReadReplicaWithinFunc) History() []ClientReadReplicaWithinFuncCall {
+	return f.history
+}
+
+// ClientReadReplicaWithinFuncCall is an object that describes an invocation
+// of method ReadReplicaWithin on an instance of MockClient.
+type ClientReadReplicaWithinFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 time.Duration
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.Client
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientReadReplicaWithinFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientReadReplicaWithinFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
+// ClientStateFunc describes the behavior when the State method of the
+// parent MockClient instance is invoked.
+type ClientStateFunc struct {
+	defaultHook func() iface.ClientState
+	hooks       []func() iface.ClientState
+	history     []ClientStateFuncCall
+}
+
+// State delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockClient) State() iface.ClientState {
+	r0 := m.StateFunc.nextHook()()
+	m.StateFunc.history = append(m.StateFunc.history, ClientStateFuncCall{r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the State method of the
+// parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientStateFunc) SetDefaultHook(hook func() iface.ClientState) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// State method of the parent MockClient instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *ClientStateFunc) PushHook(hook func() iface.ClientState) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientStateFunc) SetDefaultReturn(r0 iface.ClientState) {
+	f.SetDefaultHook(func() iface.ClientState {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientStateFunc) PushReturn(r0 iface.ClientState) {
+	f.PushHook(func() iface.ClientState {
+		return r0
+	})
+}
+
+func (f *ClientStateFunc) nextHook() func() iface.ClientState {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientStateFuncCall objects describing the
+// invocations of this function.
+func (f *ClientStateFunc) History() []ClientStateFuncCall {
+	return f.history
+}
+
+// ClientStateFuncCall is an object that describes an invocation of method
+// State on an instance of MockClient.
+type ClientStateFuncCall struct {
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.ClientState
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientStateFuncCall) Args() []interface{} {
+	return []interface{}{}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientStateFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
+// ClientStatsFunc describes the behavior when the Stats method of the
+// parent MockClient instance is invoked.
+type ClientStatsFunc struct {
+	defaultHook func() iface.ClientStats
+	hooks       []func() iface.ClientStats
+	history     []ClientStatsFuncCall
+}
+
+// Stats delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockClient) Stats() iface.ClientStats {
+	r0 := m.StatsFunc.nextHook()()
+	m.StatsFunc.history = append(m.StatsFunc.history, ClientStatsFuncCall{r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the Stats method of the
+// parent MockClient instance is invoked and the hook queue is empty.
+func (f *ClientStatsFunc) SetDefaultHook(hook func() iface.ClientStats) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// Stats method of the parent MockClient instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *ClientStatsFunc) PushHook(hook func() iface.ClientStats) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *ClientStatsFunc) SetDefaultReturn(r0 iface.ClientStats) {
+	f.SetDefaultHook(func() iface.ClientStats {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *ClientStatsFunc) PushReturn(r0 iface.ClientStats) {
+	f.PushHook(func() iface.ClientStats {
+		return r0
+	})
+}
+
+func (f *ClientStatsFunc) nextHook() func() iface.ClientStats {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of ClientStatsFuncCall objects describing the
+// invocations of this function.
+func (f *ClientStatsFunc) History() []ClientStatsFuncCall {
+	return f.history
+}
+
+// ClientStatsFuncCall is an object that describes an invocation of method
+// Stats on an instance of MockClient.
+type ClientStatsFuncCall struct {
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.ClientStats
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ClientStatsFuncCall) Args() []interface{} {
+	return []interface{}{}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ClientStatsFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
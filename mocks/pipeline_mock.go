@@ -1,12 +1,15 @@
 // Code generated by github.com/efritz/go-mockgen; DO NOT EDIT.
 // This file was generated by robots at
-// 2018-10-23T15:57:11-05:00
+// 2026-10-18T16:59:12-05:00
 // using the command
 // $ go-mockgen -f github.com/efritz/deepjoy/iface
 
 package mocks
 
-import iface "github.com/efritz/deepjoy/iface"
+import (
+	"context"
+	iface "github.com/efritz/deepjoy/iface"
+)
 
 // MockPipeline is a mock impelementation of the Pipeline interface (from
 // the package github.com/efritz/deepjoy/iface) used for unit testing.
@@ -17,6 +20,9 @@ type MockPipeline struct {
 	// RunFunc is an instance of a mock function object controlling the
 	// behavior of the method Run.
 	RunFunc *PipelineRunFunc
+	// RunContextFunc is an instance of a mock function object controlling
+	// the behavior of the method RunContext.
+	RunContextFunc *PipelineRunContextFunc
 }
 
 // NewMockPipeline creates a new mock of the Pipeline interface. All methods
@@ -33,6 +39,11 @@ func NewMockPipeline() *MockPipeline {
 				return nil, nil
 			},
 		},
+		RunContextFunc: &PipelineRunContextFunc{
+			defaultHook: func(context.Context) (interface{}, error) {
+				return nil, nil
+			},
+		},
 	}
 }
 
@@ -46,6 +57,9 @@ func NewMockPipelineFrom(i iface.Pipeline) *MockPipeline {
 		RunFunc: &PipelineRunFunc{
 			defaultHook: i.Run,
 		},
+		RunContextFunc: &PipelineRunContextFunc{
+			defaultHook: i.RunContext,
+		},
 	}
 }
 
@@ -220,3 +234,91 @@ func (c PipelineRunFuncCall) Args() []interface{} {
 func (c PipelineRunFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
+
+// PipelineRunContextFunc describes the behavior when the RunContext method
+// of the parent MockPipeline instance is invoked.
+type PipelineRunContextFunc struct {
+	defaultHook func(context.Context) (interface{}, error)
+	hooks       []func(context.Context) (interface{}, error)
+	history     []PipelineRunContextFuncCall
+}
+
+// RunContext delegates to the next hook function in the queue and stores
+// the parameter and result values of this invocation.
+func (m *MockPipeline) RunContext(v0 context.Context) (interface{}, error) {
+	r0, r1 := m.RunContextFunc.nextHook()(v0)
+	m.RunContextFunc.history = append(m.RunContextFunc.history, PipelineRunContextFuncCall{v0, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the RunContext method of
+// the parent MockPipeline instance is invoked and the hook queue is empty.
+func (f *PipelineRunContextFunc) SetDefaultHook(hook func(context.Context) (interface{}, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// RunContext method of the parent MockPipeline instance inovkes the hook at
+// the front of the queue and discards it. After the queue is empty, the
+// default hook function is invoked for any future action.
+func (f *PipelineRunContextFunc) PushHook(hook func(context.Context) (interface{}, error)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PipelineRunContextFunc) SetDefaultReturn(r0 interface{}, r1 error) {
+	f.SetDefaultHook(func(context.Context) (interface{}, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PipelineRunContextFunc) PushReturn(r0 interface{}, r1 error) {
+	f.PushHook(func(context.Context) (interface{}, error) {
+		return r0, r1
+	})
+}
+
+func (f *PipelineRunContextFunc) nextHook() func(context.Context) (interface{}, error) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PipelineRunContextFuncCall objects
+// describing the invocations of this function.
+func (f *PipelineRunContextFunc) History() []PipelineRunContextFuncCall {
+	return f.history
+}
+
+// PipelineRunContextFuncCall is an object that describes an invocation of
+// method RunContext on an instance of MockPipeline.
+type PipelineRunContextFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 interface{}
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PipelineRunContextFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PipelineRunContextFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
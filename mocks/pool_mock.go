@@ -1,12 +1,13 @@
 // Code generated by github.com/efritz/go-mockgen; DO NOT EDIT.
 // This file was generated by robots at
-// 2018-10-23T15:57:11-05:00
+// 2026-10-18T17:51:30-05:00
 // using the command
 // $ go-mockgen -f github.com/efritz/deepjoy/iface
 
 package mocks
 
 import (
+	"context"
 	iface "github.com/efritz/deepjoy/iface"
 	"time"
 )
@@ -17,15 +18,33 @@ type MockPool struct {
 	// BorrowFunc is an instance of a mock function object controlling the
 	// behavior of the method Borrow.
 	BorrowFunc *PoolBorrowFunc
+	// BorrowErrFunc is an instance of a mock function object controlling
+	// the behavior of the method BorrowErr.
+	BorrowErrFunc *PoolBorrowErrFunc
 	// BorrowTimeoutFunc is an instance of a mock function object
 	// controlling the behavior of the method BorrowTimeout.
 	BorrowTimeoutFunc *PoolBorrowTimeoutFunc
+	// BorrowTimeoutErrFunc is an instance of a mock function object
+	// controlling the behavior of the method BorrowTimeoutErr.
+	BorrowTimeoutErrFunc *PoolBorrowTimeoutErrFunc
 	// CloseFunc is an instance of a mock function object controlling the
 	// behavior of the method Close.
 	CloseFunc *PoolCloseFunc
+	// CloseContextFunc is an instance of a mock function object controlling
+	// the behavior of the method CloseContext.
+	CloseContextFunc *PoolCloseContextFunc
+	// DiscardFunc is an instance of a mock function object controlling the
+	// behavior of the method Discard.
+	DiscardFunc *PoolDiscardFunc
 	// ReleaseFunc is an instance of a mock function object controlling the
 	// behavior of the method Release.
 	ReleaseFunc *PoolReleaseFunc
+	// ResizeFunc is an instance of a mock function object controlling the
+	// behavior of the method Resize.
+	ResizeFunc *PoolResizeFunc
+	// StatsFunc is an instance of a mock function object controlling the
+	// behavior of the method Stats.
+	StatsFunc *PoolStatsFunc
 }
 
 // NewMockPool creates a new mock of the Pool interface. All methods return
@@ -37,21 +56,51 @@ func NewMockPool() *MockPool {
 				return nil, false
 			},
 		},
+		BorrowErrFunc: &PoolBorrowErrFunc{
+			defaultHook: func() (iface.Conn, error) {
+				return nil, nil
+			},
+		},
 		BorrowTimeoutFunc: &PoolBorrowTimeoutFunc{
 			defaultHook: func(time.Duration) (iface.Conn, bool) {
 				return nil, false
 			},
 		},
+		BorrowTimeoutErrFunc: &PoolBorrowTimeoutErrFunc{
+			defaultHook: func(time.Duration) (iface.Conn, error) {
+				return nil, nil
+			},
+		},
 		CloseFunc: &PoolCloseFunc{
 			defaultHook: func() {
 				return
 			},
 		},
+		CloseContextFunc: &PoolCloseContextFunc{
+			defaultHook: func(context.Context) error {
+				return nil
+			},
+		},
+		DiscardFunc: &PoolDiscardFunc{
+			defaultHook: func(iface.Conn) {
+				return
+			},
+		},
 		ReleaseFunc: &PoolReleaseFunc{
 			defaultHook: func(iface.Conn) {
 				return
 			},
 		},
+		ResizeFunc: &PoolResizeFunc{
+			defaultHook: func(int) {
+				return
+			},
+		},
+		StatsFunc: &PoolStatsFunc{
+			defaultHook: func() iface.PoolStats {
+				return iface.PoolStats{}
+			},
+		},
 	}
 }
 
@@ -62,15 +111,33 @@ func NewMockPoolFrom(i iface.Pool) *MockPool {
 		BorrowFunc: &PoolBorrowFunc{
 			defaultHook: i.Borrow,
 		},
+		BorrowErrFunc: &PoolBorrowErrFunc{
+			defaultHook: i.BorrowErr,
+		},
 		BorrowTimeoutFunc: &PoolBorrowTimeoutFunc{
 			defaultHook: i.BorrowTimeout,
 		},
+		BorrowTimeoutErrFunc: &PoolBorrowTimeoutErrFunc{
+			defaultHook: i.BorrowTimeoutErr,
+		},
 		CloseFunc: &PoolCloseFunc{
 			defaultHook: i.Close,
 		},
+		CloseContextFunc: &PoolCloseContextFunc{
+			defaultHook: i.CloseContext,
+		},
+		DiscardFunc: &PoolDiscardFunc{
+			defaultHook: i.Discard,
+		},
 		ReleaseFunc: &PoolReleaseFunc{
 			defaultHook: i.Release,
 		},
+		ResizeFunc: &PoolResizeFunc{
+			defaultHook: i.Resize,
+		},
+		StatsFunc: &PoolStatsFunc{
+			defaultHook: i.Stats,
+		},
 	}
 }
 
@@ -159,6 +226,91 @@ func (c PoolBorrowFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// PoolBorrowErrFunc describes the behavior when the BorrowErr method of the
+// parent MockPool instance is invoked.
+type PoolBorrowErrFunc struct {
+	defaultHook func() (iface.Conn, error)
+	hooks       []func() (iface.Conn, error)
+	history     []PoolBorrowErrFuncCall
+}
+
+// BorrowErr delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockPool) BorrowErr() (iface.Conn, error) {
+	r0, r1 := m.BorrowErrFunc.nextHook()()
+	m.BorrowErrFunc.history = append(m.BorrowErrFunc.history, PoolBorrowErrFuncCall{r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the BorrowErr method of
+// the parent MockPool instance is invoked and the hook queue is empty.
+func (f *PoolBorrowErrFunc) SetDefaultHook(hook func() (iface.Conn, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// BorrowErr method of the parent MockPool instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *PoolBorrowErrFunc) PushHook(hook func() (iface.Conn, error)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PoolBorrowErrFunc) SetDefaultReturn(r0 iface.Conn, r1 error) {
+	f.SetDefaultHook(func() (iface.Conn, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PoolBorrowErrFunc) PushReturn(r0 iface.Conn, r1 error) {
+	f.PushHook(func() (iface.Conn, error) {
+		return r0, r1
+	})
+}
+
+func (f *PoolBorrowErrFunc) nextHook() func() (iface.Conn, error) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PoolBorrowErrFuncCall objects describing
+// the invocations of this function.
+func (f *PoolBorrowErrFunc) History() []PoolBorrowErrFuncCall {
+	return f.history
+}
+
+// PoolBorrowErrFuncCall is an object that describes an invocation of method
+// BorrowErr on an instance of MockPool.
+type PoolBorrowErrFuncCall struct {
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.Conn
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PoolBorrowErrFuncCall) Args() []interface{} {
+	return []interface{}{}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PoolBorrowErrFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // PoolBorrowTimeoutFunc describes the behavior when the BorrowTimeout
 // method of the parent MockPool instance is invoked.
 type PoolBorrowTimeoutFunc struct {
@@ -247,6 +399,95 @@ func (c PoolBorrowTimeoutFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// PoolBorrowTimeoutErrFunc describes the behavior when the BorrowTimeoutErr
+// method of the parent MockPool instance is invoked.
+type PoolBorrowTimeoutErrFunc struct {
+	defaultHook func(time.Duration) (iface.Conn, error)
+	hooks       []func(time.Duration) (iface.Conn, error)
+	history     []PoolBorrowTimeoutErrFuncCall
+}
+
+// BorrowTimeoutErr delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockPool) BorrowTimeoutErr(v0 time.Duration) (iface.Conn, error) {
+	r0, r1 := m.BorrowTimeoutErrFunc.nextHook()(v0)
+	m.BorrowTimeoutErrFunc.history = append(m.BorrowTimeoutErrFunc.history, PoolBorrowTimeoutErrFuncCall{v0, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the BorrowTimeoutErr
+// method of the parent MockPool instance is invoked and the hook queue is
+// empty.
+func (f *PoolBorrowTimeoutErrFunc) SetDefaultHook(hook func(time.Duration) (iface.Conn, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// BorrowTimeoutErr method of the parent MockPool instance inovkes the hook
+// at the front of the queue and discards it. After the queue is empty, the
+// default hook function is invoked for any future action.
+func (f *PoolBorrowTimeoutErrFunc) PushHook(hook func(time.Duration) (iface.Conn, error)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PoolBorrowTimeoutErrFunc) SetDefaultReturn(r0 iface.Conn, r1 error) {
+	f.SetDefaultHook(func(time.Duration) (iface.Conn, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PoolBorrowTimeoutErrFunc) PushReturn(r0 iface.Conn, r1 error) {
+	f.PushHook(func(time.Duration) (iface.Conn, error) {
+		return r0, r1
+	})
+}
+
+func (f *PoolBorrowTimeoutErrFunc) nextHook() func(time.Duration) (iface.Conn, error) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PoolBorrowTimeoutErrFuncCall objects
+// describing the invocations of this function.
+func (f *PoolBorrowTimeoutErrFunc) History() []PoolBorrowTimeoutErrFuncCall {
+	return f.history
+}
+
+// PoolBorrowTimeoutErrFuncCall is an object that describes an invocation of
+// method BorrowTimeoutErr on an instance of MockPool.
+type PoolBorrowTimeoutErrFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 time.Duration
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.Conn
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PoolBorrowTimeoutErrFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PoolBorrowTimeoutErrFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // PoolCloseFunc describes the behavior when the Close method of the parent
 // MockPool instance is invoked.
 type PoolCloseFunc struct {
@@ -325,6 +566,173 @@ func (c PoolCloseFuncCall) Results() []interface{} {
 	return []interface{}{}
 }
 
+// PoolCloseContextFunc describes the behavior when the CloseContext method
+// of the parent MockPool instance is invoked.
+type PoolCloseContextFunc struct {
+	defaultHook func(context.Context) error
+	hooks       []func(context.Context) error
+	history     []PoolCloseContextFuncCall
+}
+
+// CloseContext delegates to the next hook function in the queue and stores
+// the parameter and result values of this invocation.
+func (m *MockPool) CloseContext(v0 context.Context) error {
+	r0 := m.CloseContextFunc.nextHook()(v0)
+	m.CloseContextFunc.history = append(m.CloseContextFunc.history, PoolCloseContextFuncCall{v0, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the CloseContext method
+// of the parent MockPool instance is invoked and the hook queue is empty.
+func (f *PoolCloseContextFunc) SetDefaultHook(hook func(context.Context) error) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// CloseContext method of the parent MockPool instance inovkes the hook at
+// the front of the queue and discards it. After the queue is empty, the
+// default hook function is invoked for any future action.
+func (f *PoolCloseContextFunc) PushHook(hook func(context.Context) error) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PoolCloseContextFunc) SetDefaultReturn(r0 error) {
+	f.SetDefaultHook(func(context.Context) error {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PoolCloseContextFunc) PushReturn(r0 error) {
+	f.PushHook(func(context.Context) error {
+		return r0
+	})
+}
+
+func (f *PoolCloseContextFunc) nextHook() func(context.Context) error {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PoolCloseContextFuncCall objects describing
+// the invocations of this function.
+func (f *PoolCloseContextFunc) History() []PoolCloseContextFuncCall {
+	return f.history
+}
+
+// PoolCloseContextFuncCall is an object that describes an invocation of
+// method CloseContext on an instance of MockPool.
+type PoolCloseContextFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PoolCloseContextFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PoolCloseContextFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
+// PoolDiscardFunc describes the behavior when the Discard method of the
+// parent MockPool instance is invoked.
+type PoolDiscardFunc struct {
+	defaultHook func(iface.Conn)
+	hooks       []func(iface.Conn)
+	history     []PoolDiscardFuncCall
+}
+
+// Discard delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockPool) Discard(v0 iface.Conn) {
+	m.DiscardFunc.nextHook()(v0)
+	m.DiscardFunc.history = append(m.DiscardFunc.history, PoolDiscardFuncCall{v0})
+	return
+}
+
+// SetDefaultHook sets function that is called when the Discard method of
+// the parent MockPool instance is invoked and the hook queue is empty.
+func (f *PoolDiscardFunc) SetDefaultHook(hook func(iface.Conn)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// Discard method of the parent MockPool instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *PoolDiscardFunc) PushHook(hook func(iface.Conn)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PoolDiscardFunc) SetDefaultReturn() {
+	f.SetDefaultHook(func(iface.Conn) {
+		return
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PoolDiscardFunc) PushReturn() {
+	f.PushHook(func(iface.Conn) {
+		return
+	})
+}
+
+func (f *PoolDiscardFunc) nextHook() func(iface.Conn) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PoolDiscardFuncCall objects describing the
+// invocations of this function.
+func (f *PoolDiscardFunc) History() []PoolDiscardFuncCall {
+	return f.history
+}
+
+// PoolDiscardFuncCall is an object that describes an invocation of method
+// Discard on an instance of MockPool.
+type PoolDiscardFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 iface.Conn
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PoolDiscardFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PoolDiscardFuncCall) Results() []interface{} {
+	return []interface{}{}
+}
+
 // PoolReleaseFunc describes the behavior when the Release method of the
 // parent MockPool instance is invoked.
 type PoolReleaseFunc struct {
@@ -406,3 +814,167 @@ func (c PoolReleaseFuncCall) Args() []interface{} {
 func (c PoolReleaseFuncCall) Results() []interface{} {
 	return []interface{}{}
 }
+
+// PoolResizeFunc describes the behavior when the Resize method of the
+// parent MockPool instance is invoked.
+type PoolResizeFunc struct {
+	defaultHook func(int)
+	hooks       []func(int)
+	history     []PoolResizeFuncCall
+}
+
+// Resize delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockPool) Resize(v0 int) {
+	m.ResizeFunc.nextHook()(v0)
+	m.ResizeFunc.history = append(m.ResizeFunc.history, PoolResizeFuncCall{v0})
+	return
+}
+
+// SetDefaultHook sets function that is called when the Resize method of the
+// parent MockPool instance is invoked and the hook queue is empty.
+func (f *PoolResizeFunc) SetDefaultHook(hook func(int)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// Resize method of the parent MockPool instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *PoolResizeFunc) PushHook(hook func(int)) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PoolResizeFunc) SetDefaultReturn() {
+	f.SetDefaultHook(func(int) {
+		return
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PoolResizeFunc) PushReturn() {
+	f.PushHook(func(int) {
+		return
+	})
+}
+
+func (f *PoolResizeFunc) nextHook() func(int) {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PoolResizeFuncCall objects describing the
+// invocations of this function.
+func (f *PoolResizeFunc) History() []PoolResizeFuncCall {
+	return f.history
+}
+
+// PoolResizeFuncCall is an object that describes an invocation of method
+// Resize on an instance of MockPool.
+type PoolResizeFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 int
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PoolResizeFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PoolResizeFuncCall) Results() []interface{} {
+	return []interface{}{}
+}
+
+// PoolStatsFunc describes the behavior when the Stats method of the parent
+// MockPool instance is invoked.
+type PoolStatsFunc struct {
+	defaultHook func() iface.PoolStats
+	hooks       []func() iface.PoolStats
+	history     []PoolStatsFuncCall
+}
+
+// Stats delegates to the next hook function in the queue and stores the
+// parameter and result values of this invocation.
+func (m *MockPool) Stats() iface.PoolStats {
+	r0 := m.StatsFunc.nextHook()()
+	m.StatsFunc.history = append(m.StatsFunc.history, PoolStatsFuncCall{r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the Stats method of the
+// parent MockPool instance is invoked and the hook queue is empty.
+func (f *PoolStatsFunc) SetDefaultHook(hook func() iface.PoolStats) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// Stats method of the parent MockPool instance inovkes the hook at the
+// front of the queue and discards it. After the queue is empty, the default
+// hook function is invoked for any future action.
+func (f *PoolStatsFunc) PushHook(hook func() iface.PoolStats) {
+	f.hooks = append(f.hooks, hook)
+}
+
+// SetDefaultReturn calls SetDefaultDefaultHook with a function that returns
+// the given values.
+func (f *PoolStatsFunc) SetDefaultReturn(r0 iface.PoolStats) {
+	f.SetDefaultHook(func() iface.PoolStats {
+		return r0
+	})
+}
+
+// PushReturn calls PushDefaultHook with a function that returns the given
+// values.
+func (f *PoolStatsFunc) PushReturn(r0 iface.PoolStats) {
+	f.PushHook(func() iface.PoolStats {
+		return r0
+	})
+}
+
+func (f *PoolStatsFunc) nextHook() func() iface.PoolStats {
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+// History returns a sequence of PoolStatsFuncCall objects describing the
+// invocations of this function.
+func (f *PoolStatsFunc) History() []PoolStatsFuncCall {
+	return f.history
+}
+
+// PoolStatsFuncCall is an object that describes an invocation of method
+// Stats on an instance of MockPool.
+type PoolStatsFuncCall struct {
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 iface.PoolStats
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c PoolStatsFuncCall) Args() []interface{} {
+	return []interface{}{}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c PoolStatsFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
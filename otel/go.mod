@@ -0,0 +1,31 @@
+module github.com/efritz/deepjoy/otel
+
+go 1.27.1
+
+require (
+	github.com/efritz/deepjoy v0.0.0
+	github.com/onsi/gomega v1.4.3
+	go.opentelemetry.io/otel v1.43.0
+	go.opentelemetry.io/otel/sdk v1.43.0
+	go.opentelemetry.io/otel/trace v1.43.0
+)
+
+require (
+	github.com/bradhe/stopwatch v0.0.0-20180424000511-fd55e776a960 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/efritz/backoff v0.0.0-20181228195520-96f666d52d44 // indirect
+	github.com/efritz/glock v0.0.0-20180604185841-7e95e8b27a61 // indirect
+	github.com/efritz/overcurrent v0.0.0-20180604185922-c4abdcffd8c0 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/gomodule/redigo v2.0.0+incompatible // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.43.0 // indirect
+	golang.org/x/net v0.0.0-20181220203305-927f97764cc3 // indirect
+	golang.org/x/sys v0.42.0 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/efritz/deepjoy => ../
@@ -0,0 +1,69 @@
+// Package otel adapts an OpenTelemetry tracer to the deepjoy Tracer
+// interface. It is a separate module so that clients which do not use
+// OpenTelemetry do not depend on it.
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/efritz/deepjoy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	tracer struct {
+		tracer trace.Tracer
+	}
+
+	span struct {
+		span trace.Span
+	}
+)
+
+// NewTracer creates a deepjoy Tracer which starts client spans with the
+// given OpenTelemetry tracer. Spans are children of the span in the context
+// passed to DoContext or RunContext, so trace context extracted from an
+// incoming request by an OpenTelemetry propagator (e.g. W3C trace context)
+// is carried into the spans of the client.
+func NewTracer(t trace.Tracer) deepjoy.Tracer {
+	return &tracer{tracer: t}
+}
+
+func (t *tracer) StartSpan(ctx context.Context, name string) (context.Context, deepjoy.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &span{span: s}
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(keyValue(key, value))
+}
+
+func (s *span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *span) End() {
+	s.span.End()
+}
+
+// Convert an attribute value to the matching OpenTelemetry type.
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	}
+
+	return attribute.String(key, fmt.Sprint(value))
+}
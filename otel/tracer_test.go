@@ -0,0 +1,41 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	var (
+		g        = NewGomegaWithT(t)
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer   = NewTracer(provider.Tracer("deepjoy"))
+	)
+
+	ctx, parent := tracer.StartSpan(context.Background(), "GET")
+	parent.SetAttribute("db.operation", "GET")
+	parent.SetAttribute("db.redis.database_index", 3)
+
+	_, child := tracer.StartSpan(ctx, "redis.attempt")
+	child.RecordError(errors.New("oops"))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(2))
+	g.Expect(spans[0].Name()).To(Equal("redis.attempt"))
+	g.Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+	g.Expect(spans[0].Status().Code).To(Equal(codes.Error))
+	g.Expect(spans[1].Attributes()).To(ConsistOf(
+		attribute.String("db.operation", "GET"),
+		attribute.Int("db.redis.database_index", 3),
+	))
+}
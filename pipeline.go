@@ -1,6 +1,10 @@
 package deepjoy
 
-import "github.com/efritz/deepjoy/iface"
+import (
+	"context"
+
+	"github.com/efritz/deepjoy/iface"
+)
 
 type (
 	// Pipeline wraps an ordered sequence of commands to be processed
@@ -9,19 +13,23 @@ type (
 	Pipeline = iface.Pipeline
 
 	pipeline struct {
-		client   *client
+		runner   pipelineRunner
 		commands []commandPair
 	}
 
+	pipelineRunner interface {
+		pipeline(ctx context.Context, commands []commandPair) (interface{}, error)
+	}
+
 	commandPair struct {
 		command string
 		args    []interface{}
 	}
 )
 
-func newPipeline(client *client) Pipeline {
+func newPipeline(runner pipelineRunner) Pipeline {
 	return &pipeline{
-		client:   client,
+		runner:   runner,
 		commands: []commandPair{},
 	}
 }
@@ -39,5 +47,12 @@ func (p *pipeline) Add(command string, args ...interface{}) {
 // single request and return a slice of the results of each
 // command.
 func (p *pipeline) Run() (interface{}, error) {
-	return p.client.pipeline(p.commands)
+	return p.RunContext(context.Background())
+}
+
+// RunContext is like Run, but the given context is passed to the
+// registered hooks and tracer, and bounds the time spent borrowing a
+// connection and backing off between retries.
+func (p *pipeline) RunContext(ctx context.Context) (interface{}, error) {
+	return p.runner.pipeline(ctx, p.commands)
 }
@@ -2,7 +2,10 @@ package deepjoy
 
 import (
 	"context"
+	"errors"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/efritz/glock"
@@ -12,18 +15,93 @@ import (
 )
 
 type (
-	// Pool abstracts a fixed-size Redis connection pool.
+	// Pool abstracts a Redis connection pool.
 	Pool = iface.Pool
 
+	// PoolStats is a snapshot of the state of a connection pool.
+	PoolStats = iface.PoolStats
+
 	pool struct {
-		dialer         DialFunc
-		capacity       int
-		logger         Logger
-		breakerFunc    BreakerFunc
-		clock          glock.Clock
-		connections    chan Conn
-		nilConnections chan Conn
-		mutex          sync.RWMutex
+		counters     poolCounters
+		dialer       DialFunc
+		logger       LeveledLogger
+		breakerFunc  BreakerFunc
+		clock        glock.Clock
+		maxIdleTime  time.Duration
+		maxLifetime  time.Duration
+		maxIdleConns int
+		minIdleConns int
+		maxWaiters   int
+		checkIdle    time.Duration
+		adaptive     *adaptiveSizer
+		leaks        *leakDetector
+		events       eventEmitter
+		breakerOpen  int32
+		created      map[Conn]time.Time
+		forced       map[Conn]struct{}
+		createdMutex sync.Mutex
+		dialSlots    chan struct{}
+		dialFailed   chan struct{}
+		dialErr      error
+		dialMutex    sync.Mutex
+		halt         chan struct{}
+		haltOnce     sync.Once
+		wg           sync.WaitGroup
+
+		// The following fields are guarded by the mutex. The capacity of
+		// the pool is split between idle connections, nil placeholders,
+		// and borrowed values. Each time a nil value is borrowed, a new
+		// connection is established and used in its place.
+
+		mutex     sync.Mutex
+		capacity  int
+		idle      []idleConn
+		nils      int
+		borrowed  int
+		surplus   int
+		waiters   []*waiter
+		closed    bool
+		drained   chan struct{}
+		drainOnce sync.Once
+	}
+
+	// waiter is a borrower blocked on an empty pool. Values are handed
+	// to waiters directly in the order in which they began waiting, so
+	// that a borrower cannot be starved by later arrivals. A nil value
+	// is handed over as a zero idleConn, and the channel is closed if
+	// the pool is closed while the borrower is waiting. A borrower which
+	// is already dialing a new connection is only handed idle connections.
+	waiter struct {
+		ready   chan idleConn
+		dialing bool
+	}
+
+	// dialResult is the outcome of a dial made on behalf of a borrower.
+	dialResult struct {
+		conn Conn
+		err  error
+	}
+
+	// poolCounters are updated atomically. This struct is the first field
+	// of the pool so that the counters are 64-bit aligned.
+	poolCounters struct {
+		open              int64
+		dials             uint64
+		dialFailures      uint64
+		breakerRejections uint64
+		borrowWaits       uint64
+		borrowTimeouts    uint64
+		waitDuration      int64
+		errorClosed       uint64
+		reaped            uint64
+	}
+
+	// idleConn is a live connection which is not currently borrowed,
+	// along with the time it was dialed and the time it was released.
+	idleConn struct {
+		conn     Conn
+		created  time.Time
+		released time.Time
 	}
 
 	// BreakerFunc bridges the interface between the Call function of
@@ -31,126 +109,1021 @@ type (
 	BreakerFunc func(overcurrent.BreakerFunc) error
 )
 
+// ErrPoolExhausted is returned when a borrow is attempted while the
+// maximum number of borrowers are already waiting for a connection.
+var ErrPoolExhausted = errors.New("too many borrowers waiting for a connection")
+
+// ErrPoolClosed is returned when a borrow is attempted after the pool
+// has been closed.
+var ErrPoolClosed = errors.New("pool is closed")
+
+// defaultMaintenanceInterval is the interval at which the minimum number of
+// idle connections is replenished when connections do not otherwise expire.
+const defaultMaintenanceInterval = time.Second * 5
+
 func noopBreakerFunc(f overcurrent.BreakerFunc) error {
 	return f(context.Background())
 }
 
-// NewPool creates a pool with initially nil-connections.
+// NewPool creates a pool with initially nil-connections. The config
+// functions WithMaxIdleTime, WithMaxConnLifetime, WithMaxIdleConns, and
+// WithMinIdleConns can be supplied to control the number and age of idle
+// connections, WithBorrowHealthCheck can be supplied to check idle
+// connections before they are borrowed, WithMaxWaiters can be supplied to
+// limit the number of blocked borrowers, WithAdaptivePoolCapacity can be
+// supplied to resize the pool based on load, and WithLeakDetection can be
+// supplied to find misbehaving borrowers. All other config functions are
+// ignored.
 func NewPool(
 	dialer DialFunc,
 	capacity int,
 	logger Logger,
 	breakerFunc BreakerFunc,
 	clock glock.Clock,
+	configs ...ConfigFunc,
 ) Pool {
+	config := &clientConfig{}
+	for _, f := range configs {
+		f(config)
+	}
+
+	config.poolCapacity = capacity
+	config.logger = leveledLogger(logger)
+	config.breakerFunc = breakerFunc
+	config.clock = clock
+
+	return newPool(dialer, config, eventEmitter{})
+}
+
+func newPool(dialer DialFunc, config *clientConfig, events eventEmitter) *pool {
+	clock := config.clock
+	if clock == nil {
+		clock = glock.NewRealClock()
+	}
+
 	p := &pool{
-		dialer:         dialer,
-		capacity:       capacity,
-		logger:         logger,
-		breakerFunc:    breakerFunc,
-		clock:          clock,
-		connections:    make(chan Conn, capacity),
-		nilConnections: make(chan Conn, capacity),
+		dialer:       dialer,
+		logger:       config.logger,
+		breakerFunc:  config.breakerFunc,
+		clock:        clock,
+		maxIdleTime:  config.maxIdleTime,
+		maxLifetime:  config.maxLifetime,
+		maxIdleConns: config.maxIdleConns,
+		minIdleConns: config.minIdleConns,
+		maxWaiters:   config.maxWaiters,
+		checkIdle:    config.borrowCheckIdle,
+		events:       events,
+		created:      map[Conn]time.Time{},
+		forced:       map[Conn]struct{}{},
+		dialFailed:   make(chan struct{}),
+		halt:         make(chan struct{}),
+		capacity:     config.poolCapacity,
+		nils:         config.poolCapacity,
 	}
 
-	// Set the capacity of the pool. Each time a nil value is borrowed, a new
-	// connection is established and used in its place.
+	if config.maxConcurrentDials > 0 {
+		p.dialSlots = make(chan struct{}, config.maxConcurrentDials)
+	}
+
+	if config.leakThreshold > 0 {
+		p.leaks = newLeakDetector(config.leakThreshold, p.logger, clock)
+	}
 
-	for i := 0; i < p.capacity; i++ {
-		p.nilConnections <- nil
+	if config.adaptiveMax > 0 {
+		p.adaptive = newAdaptiveSizer(config, p.clock.Now())
+		p.capacity = p.adaptive.clamp(p.capacity)
+		p.nils = p.capacity
+	}
+
+	if interval := p.maintenanceInterval(); interval > 0 {
+		p.wg.Add(1)
+		go p.maintain(interval)
 	}
 
 	return p
 }
 
 func (p *pool) Close() {
-	for i := 0; i < p.capacity; i++ {
-		if conn, _ := p.get(nil); conn != nil {
-			if err := conn.Close(); err != nil {
-				p.logger.Printf("Could not close connection (%s)", err.Error())
-			}
+	p.CloseContext(context.Background())
+}
+
+func (p *pool) CloseContext(ctx context.Context) error {
+	p.haltOnce.Do(func() { close(p.halt) })
+	p.wg.Wait()
+
+	p.mutex.Lock()
+	p.closed = true
+
+	// Wake all waiting borrowers
+	for _, w := range p.waiters {
+		close(w.ready)
+	}
+
+	p.waiters = nil
+
+	// Connections released from here on are closed on release, so
+	// idle connections can be closed without waiting for borrowers.
+	idle := p.idle
+	p.idle = nil
+
+	// Concurrent and repeated calls share the same channel, which is
+	// closed once every borrowed value has been returned.
+	if p.drained == nil {
+		p.drained = make(chan struct{})
+	}
+
+	drained := p.drained
+	p.signalDrained()
+	p.mutex.Unlock()
+
+	for _, entry := range idle {
+		p.closeConn(entry.conn)
+	}
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+
+	if p.leaks != nil {
+		p.leaks.report()
+	}
+
+	// Close the connections which are still borrowed. The borrowers
+	// will receive an error on their next use of the connection, and
+	// the connection is not closed a second time when it is released.
+
+	p.createdMutex.Lock()
+	borrowed := make([]Conn, 0, len(p.created))
+	for conn := range p.created {
+		borrowed = append(borrowed, conn)
+		p.forced[conn] = struct{}{}
+		delete(p.created, conn)
+	}
+	p.createdMutex.Unlock()
+
+	p.logger.Warn("Closing connections which were not released before the close deadline", "count", len(borrowed))
+
+	for _, conn := range borrowed {
+		if err := conn.Close(); err != nil {
+			p.logger.Warn("Could not close connection", "error", err)
 		}
 	}
 
-	close(p.connections)
-	close(p.nilConnections)
+	return ctx.Err()
 }
 
 func (p *pool) Borrow() (Conn, bool) {
-	if conn, _ := p.get(nil); conn != nil {
-		return conn, true
+	conn, err := p.borrow(nil)
+	return conn, err == nil
+}
+
+func (p *pool) BorrowTimeout(timeout time.Duration) (Conn, bool) {
+	conn, err := p.borrow(&timeout)
+	return conn, err == nil
+}
+
+func (p *pool) BorrowErr() (Conn, error) {
+	return p.borrow(nil)
+}
+
+func (p *pool) BorrowTimeoutErr(timeout time.Duration) (Conn, error) {
+	return p.borrow(&timeout)
+}
+
+func (p *pool) Release(conn Conn) {
+	if conn == nil {
+		p.releaseNil()
+		return
+	}
+
+	if p.leaks != nil {
+		var closed, ok bool
+		if conn, closed, ok = p.leaks.untrack(conn); !ok {
+			return
+		}
+
+		if closed {
+			p.forget(conn, true)
+			return
+		}
 	}
 
-	return p.dial()
+	p.release(conn)
 }
 
-func (p *pool) BorrowTimeout(timeout time.Duration) (Conn, bool) {
-	if conn, ok := p.get(&timeout); conn != nil || !ok {
-		return conn, ok
+func (p *pool) Discard(conn Conn) {
+	closed := false
+	if p.leaks != nil {
+		var ok bool
+		if conn, closed, ok = p.leaks.untrack(conn); !ok {
+			return
+		}
 	}
 
-	return p.dial()
+	if conn == nil {
+		p.releaseNil()
+		return
+	}
+
+	p.forget(conn, closed)
 }
 
-func (p *pool) Release(conn Conn) {
+// Free the place of a connection which the borrower released as a nil value.
+// The pool cannot tell which connection was lost, so it remains tracked until
+// the pool is closed. This is logged as an error so that the caller is fixed
+// to pass the connection to Discard instead.
+func (p *pool) releaseNil() {
+	p.logger.Error("Released a nil connection, which leaks the connection it replaces (use Discard instead)", "stack", string(debug.Stack()))
+	p.release(nil)
+}
+
+// Return a borrowed value to the pool. Unlike Release, the value is never
+// wrapped by the leak detector.
+func (p *pool) release(conn Conn) {
 	if conn == nil {
-		p.nilConnections <- conn
-	} else {
-		p.connections <- conn
+		// The borrower closed the connection after an error
+		atomic.AddInt64(&p.counters.open, -1)
+		atomic.AddUint64(&p.counters.errorClosed, 1)
+		p.putNil()
+		return
+	}
+
+	now := p.clock.Now()
+
+	p.createdMutex.Lock()
+	created, ok := p.created[conn]
+	_, forced := p.forced[conn]
+	delete(p.created, conn)
+	delete(p.forced, conn)
+	p.createdMutex.Unlock()
+
+	if forced {
+		// The connection was closed after the close deadline elapsed
+		atomic.AddInt64(&p.counters.open, -1)
+		p.putNil()
+		return
+	}
+
+	if !ok {
+		created = now
+	}
+
+	entry := idleConn{conn: conn, created: created, released: now}
+
+	if p.expired(entry, now) {
+		p.logger.Debug("Closing connection which exceeded its maximum lifetime")
+		atomic.AddUint64(&p.counters.reaped, 1)
+		p.discard(conn)
+		return
+	}
+
+	p.mutex.Lock()
+
+	if p.closed {
+		p.borrowed--
+		p.dispatch()
+		p.mutex.Unlock()
+
+		p.closeConn(conn)
+		return
+	}
+
+	if p.surplus > 0 {
+		p.removeSurplus()
+		p.mutex.Unlock()
+
+		p.logger.Debug("Closing connection in excess of the pool capacity")
+		atomic.AddUint64(&p.counters.reaped, 1)
+		p.closeConn(conn)
+		return
+	}
+
+	if p.maxIdleConns > 0 && len(p.idle) >= p.maxIdleConns {
+		p.mutex.Unlock()
+
+		p.logger.Debug("Closing connection in excess of the maximum idle connections")
+		atomic.AddUint64(&p.counters.reaped, 1)
+		p.discard(conn)
+		return
+	}
+
+	p.borrowed--
+	p.idle = append(p.idle, entry)
+	p.dispatch()
+	p.mutex.Unlock()
+}
+
+func (p *pool) Resize(capacity int) {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	p.mutex.Lock()
+
+	if p.closed {
+		p.mutex.Unlock()
+		return
+	}
+
+	delta := capacity - p.capacity
+	p.capacity = capacity
+
+	if delta >= 0 {
+		// Cancel any pending shrink before adding placeholders
+		n := delta
+		if n > p.surplus {
+			n = p.surplus
+		}
+
+		p.surplus -= n
+		p.nils += delta - n
+		p.dispatch()
+		p.mutex.Unlock()
+
+		p.logger.Info("Resized pool", "capacity", capacity)
+		return
+	}
+
+	// Remove surplus capacity in order of least disruption: first nil
+	// placeholders, then idle connections (oldest first), and finally
+	// connections which are currently borrowed (once they are released).
+
+	surplus := -delta
+
+	n := surplus
+	if n > p.nils {
+		n = p.nils
+	}
+
+	p.nils -= n
+	surplus -= n
+
+	n = surplus
+	if n > len(p.idle) {
+		n = len(p.idle)
+	}
+
+	closing := append([]idleConn(nil), p.idle[:n]...)
+	p.idle = p.idle[n:]
+	p.surplus += surplus - n
+	p.mutex.Unlock()
+
+	for _, entry := range closing {
+		atomic.AddUint64(&p.counters.reaped, 1)
+		p.closeConn(entry.conn)
+	}
+
+	p.logger.Info("Resized pool", "capacity", capacity)
+}
+
+func (p *pool) Stats() PoolStats {
+	p.mutex.Lock()
+	capacity, idle := p.capacity, len(p.idle)
+	p.mutex.Unlock()
+
+	open := int(atomic.LoadInt64(&p.counters.open))
+
+	return PoolStats{
+		Capacity:          capacity,
+		Open:              open,
+		Idle:              idle,
+		InUse:             open - idle,
+		Dials:             atomic.LoadUint64(&p.counters.dials),
+		DialFailures:      atomic.LoadUint64(&p.counters.dialFailures),
+		BreakerRejections: atomic.LoadUint64(&p.counters.breakerRejections),
+		BreakerOpen:       atomic.LoadInt32(&p.breakerOpen) == 1,
+		BorrowWaits:       atomic.LoadUint64(&p.counters.borrowWaits),
+		BorrowTimeouts:    atomic.LoadUint64(&p.counters.borrowTimeouts),
+		WaitDuration:      time.Duration(atomic.LoadInt64(&p.counters.waitDuration)),
+		ErrorClosed:       atomic.LoadUint64(&p.counters.errorClosed),
+		Reaped:            atomic.LoadUint64(&p.counters.reaped),
 	}
 }
 
 //
 // Pool Helper Functions
 
+// Get a value from the pool, dialing a new connection in place of a nil
+// value. If timeout is nil, no timeout is applied.
+func (p *pool) borrow(timeout *time.Duration) (Conn, error) {
+	start := p.clock.Now()
+
+	conn, err := p.get(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if conn == nil {
+		if timeout != nil {
+			remaining := *timeout - p.clock.Since(start)
+			timeout = &remaining
+		}
+
+		if conn, err = p.dialOrWait(timeout); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.leaks != nil {
+		conn = p.leaks.track(conn)
+	}
+
+	return conn, nil
+}
+
 // Get a value from the pool. If timeout is nil, no timeout is applied.
-// This method attempts to read from the non-nil connection channel first
-// in order to minimize the number of open connections when the pool is
-// not under heavy concurrent load.
-func (p *pool) get(timeout *time.Duration) (Conn, bool) {
-	select {
-	case conn := <-p.connections:
-		return conn, true
-	default:
+// This method returns idle connections before nil placeholders in order
+// to minimize the number of open connections when the pool is not under
+// heavy concurrent load. A nil connection with a nil error indicates that
+// the caller must dial a new connection.
+func (p *pool) get(timeout *time.Duration) (Conn, error) {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.mutex.Unlock()
+		return nil, ErrPoolClosed
 	}
 
-	select {
-	case conn := <-p.connections:
-		return conn, true
+	if len(p.idle) > 0 {
+		entry := p.popIdle()
+		p.borrowed++
+		p.mutex.Unlock()
+
+		return p.checkout(entry), nil
+	}
+
+	if p.nils > 0 {
+		p.nils--
+		p.borrowed++
+		p.mutex.Unlock()
+
+		return nil, nil
+	}
+
+	if p.maxWaiters > 0 && len(p.waiters) >= p.maxWaiters {
+		p.mutex.Unlock()
+		p.events.emit(Event{Type: PoolExhausted, Err: ErrPoolExhausted})
+		return nil, ErrPoolExhausted
+	}
+
+	// Nothing is immediately available, so we need to wait for another
+	// borrower to release a value back to the pool. Values are handed to
+	// waiting borrowers in the order in which they join the queue.
+
+	w := &waiter{ready: make(chan idleConn, 1)}
+	p.waiters = append(p.waiters, w)
+	p.mutex.Unlock()
+
+	start := p.clock.Now()
+	atomic.AddUint64(&p.counters.borrowWaits, 1)
 
-	case conn := <-p.nilConnections:
-		return conn, true
+	defer func() {
+		atomic.AddInt64(&p.counters.waitDuration, int64(p.clock.Since(start)))
+	}()
 
+	select {
+	case entry, ok := <-w.ready:
+		return p.receive(entry, ok)
 	case <-makeTimeoutChan(timeout, p.clock):
-		return nil, false
 	}
+
+	p.mutex.Lock()
+	removed := p.removeWaiter(w)
+	p.mutex.Unlock()
+
+	if !removed {
+		// A value was handed to us concurrently with the timeout
+		entry, ok := <-w.ready
+		return p.receive(entry, ok)
+	}
+
+	atomic.AddUint64(&p.counters.borrowTimeouts, 1)
+	p.events.emit(Event{Type: PoolExhausted, Err: ErrNoConnection})
+	return nil, ErrNoConnection
+}
+
+// Convert a value handed to a waiting borrower into the result of get.
+func (p *pool) receive(entry idleConn, ok bool) (Conn, error) {
+	if !ok {
+		return nil, ErrPoolClosed
+	}
+
+	if entry.conn == nil {
+		return nil, nil
+	}
+
+	return p.checkout(entry), nil
+}
+
+// Remove a waiter from the queue. This returns false if the waiter has
+// already been handed a value (or the pool has closed). This method must
+// be called while holding the mutex.
+func (p *pool) removeWaiter(w *waiter) bool {
+	for i, other := range p.waiters {
+		if other == w {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Hand idle connections and nil placeholders to waiting borrowers in the
+// order in which they began waiting. If the pool is closing, this also
+// wakes the call to Close once all borrowed values have been returned.
+// This method must be called while holding the mutex.
+func (p *pool) dispatch() {
+	for i := 0; i < len(p.waiters) && (len(p.idle) > 0 || p.nils > 0); {
+		w := p.waiters[i]
+
+		if len(p.idle) > 0 {
+			w.ready <- p.popIdle()
+		} else if !w.dialing {
+			w.ready <- idleConn{}
+			p.nils--
+		} else {
+			i++
+			continue
+		}
+
+		p.borrowed++
+		p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+	}
+
+	p.signalDrained()
+}
+
+// Close the drained channel if the pool is closing and every borrowed value
+// has been returned. This method must be called while holding the mutex.
+func (p *pool) signalDrained() {
+	if p.drained != nil && p.borrowed == 0 {
+		p.drainOnce.Do(func() { close(p.drained) })
+	}
+}
+
+// Return a nil placeholder to the pool in place of a borrowed value.
+func (p *pool) putNil() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.surplus > 0 {
+		p.removeSurplus()
+		return
+	}
+
+	p.borrowed--
+	p.nils++
+	p.dispatch()
+}
+
+// Remove a borrowed value from the pool after the capacity has shrunk.
+// This method must be called while holding the mutex.
+func (p *pool) removeSurplus() {
+	p.surplus--
+	p.borrowed--
+	p.dispatch()
 }
 
 // Dial a new Redis connection. The call ot the dialer function is wrapped
 // in a circuit breaker so that if the remote end is down we are not going
 // to hammer it.
-func (p *pool) dial() (Conn, bool) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
+func (p *pool) dial() (Conn, error) {
+	if err := p.acquireDialSlot(); err != nil {
+		p.putNil()
+		return nil, err
+	}
+
+	defer p.releaseDialSlot()
+
+	var (
+		conn      Conn
+		attempted bool
+	)
 
-	var conn Conn
 	err := p.breakerFunc(func(ctx context.Context) error {
+		attempted = true
+		atomic.AddUint64(&p.counters.dials, 1)
+
+		start := p.clock.Now()
 		temp, err := p.dialer()
+		duration := p.clock.Since(start)
+
+		if err != nil {
+			atomic.AddUint64(&p.counters.dialFailures, 1)
+			p.events.emit(Event{Type: DialFailed, Err: err, Duration: duration})
+		} else {
+			p.events.emit(Event{Type: DialSucceeded, Duration: duration})
+		}
+
 		conn = temp
 		return err
 	})
 
 	if err != nil {
+		if !attempted {
+			atomic.AddUint64(&p.counters.breakerRejections, 1)
+
+			// The state of the breaker is not observable, so it is
+			// considered open from the first rejected dial until the
+			// next successful dial.
+			if atomic.CompareAndSwapInt32(&p.breakerOpen, 0, 1) {
+				p.events.emit(Event{Type: BreakerStateChanged, Err: err})
+			}
+		}
+
 		// We were dialing a nil connection, put this back in the pool
 		// so that we're not draining our pool on connection errors.
-		p.nilConnections <- nil
+		p.putNil()
+
+		if !attempted {
+			p.shareDialFailure(err)
+		}
+
+		p.logger.Error("Could not connect to Redis", "error", err)
+		return nil, err
+	}
+
+	p.logger.Debug("Established a new connection with Redis")
+
+	if atomic.CompareAndSwapInt32(&p.breakerOpen, 1, 0) {
+		p.events.emit(Event{Type: BreakerStateChanged, Healthy: true})
+	}
+
+	atomic.AddInt64(&p.counters.open, 1)
+	p.track(conn, p.clock.Now())
+	return conn, nil
+}
+
+// Wait until fewer than the maximum number of dials are in progress. If
+// another dial is rejected by the circuit breaker while waiting, its error
+// is returned instead so that borrowers do not each wait in turn only to be
+// rejected as well.
+func (p *pool) acquireDialSlot() error {
+	if p.dialSlots == nil {
+		return nil
+	}
+
+	p.dialMutex.Lock()
+	failed := p.dialFailed
+	p.dialMutex.Unlock()
+
+	select {
+	case p.dialSlots <- struct{}{}:
+		return nil
+	case <-failed:
+	}
+
+	p.dialMutex.Lock()
+	defer p.dialMutex.Unlock()
+	return p.dialErr
+}
+
+func (p *pool) releaseDialSlot() {
+	if p.dialSlots != nil {
+		<-p.dialSlots
+	}
+}
+
+// Fail each dial which is waiting for a dial slot with the given error. This
+// is called only when the circuit breaker rejects a dial. Any other failure
+// (e.g. a reset connection) may be transient, so waiting dials are still
+// attempted.
+func (p *pool) shareDialFailure(err error) {
+	if p.dialSlots == nil {
+		return
+	}
+
+	p.dialMutex.Lock()
+	p.dialErr = err
+	close(p.dialFailed)
+	p.dialFailed = make(chan struct{})
+	p.dialMutex.Unlock()
+}
+
+// Dial a new connection in place of a nil value. While the dial is in
+// progress the borrower also waits in line for an idle connection, and
+// takes whichever is available first. A connection dialed on behalf of a
+// borrower which has given up is released to the pool.
+func (p *pool) dialOrWait(timeout *time.Duration) (Conn, error) {
+	result := make(chan dialResult, 1)
+	go func() {
+		conn, err := p.dial()
+		result <- dialResult{conn, err}
+	}()
+
+	timer := makeTimeoutChan(timeout, p.clock)
+
+	for {
+		w := &waiter{ready: make(chan idleConn, 1), dialing: true}
+
+		p.mutex.Lock()
+		closed := p.closed
+		if !closed {
+			p.waiters = append(p.waiters, w)
+			p.dispatch()
+		}
+		p.mutex.Unlock()
 
-		p.logger.Printf("Could not connect to Redis (%s)", err.Error())
-		return nil, false
+		if closed {
+			p.abandonDial(result)
+			return nil, ErrPoolClosed
+		}
+
+		select {
+		case r := <-result:
+			if !p.cancelWait(w) {
+				// An idle connection was handed to us concurrently
+				// with the dial completing, so put it back
+				if entry, ok := <-w.ready; ok {
+					p.restore(entry)
+				}
+			}
+
+			if r.err != nil {
+				return nil, ErrNoConnection
+			}
+
+			return r.conn, nil
+
+		case entry, ok := <-w.ready:
+			if !ok {
+				p.abandonDial(result)
+				return nil, ErrPoolClosed
+			}
+
+			if conn := p.checkout(entry); conn != nil {
+				p.abandonDial(result)
+				return conn, nil
+			}
+
+			// The idle connection was unusable, so continue to wait
+			// for the dial without holding its place in the pool
+			p.putNil()
+
+		case <-timer:
+			if !p.cancelWait(w) {
+				if entry, ok := <-w.ready; ok {
+					p.restore(entry)
+				}
+			}
+
+			p.abandonDial(result)
+			atomic.AddUint64(&p.counters.borrowTimeouts, 1)
+			return nil, ErrNoConnection
+		}
 	}
+}
 
-	p.logger.Printf("Established a new connection with Redis")
-	return conn, true
+// Release the connection dialed on behalf of a borrower which has given up.
+func (p *pool) abandonDial(result <-chan dialResult) {
+	go func() {
+		if r := <-result; r.err == nil {
+			p.release(r.conn)
+		}
+	}()
+}
+
+// Remove a waiter from the queue while holding the mutex.
+func (p *pool) cancelWait(w *waiter) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.removeWaiter(w)
+}
+
+// Return an idle connection which was handed to a borrower that no longer
+// needs it. The connection is the next to be reused.
+func (p *pool) restore(entry idleConn) {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.borrowed--
+		p.dispatch()
+		p.mutex.Unlock()
+
+		p.closeConn(entry.conn)
+		return
+	}
+
+	p.borrowed--
+	p.idle = append(p.idle, entry)
+	p.dispatch()
+	p.mutex.Unlock()
+}
+
+// Remove the most recently released idle connection. Reusing the most
+// recently released connection first leaves connections in excess of the
+// load idle for long enough to be reaped. This method must be called while
+// holding the mutex.
+func (p *pool) popIdle() idleConn {
+	entry := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return entry
+}
+
+// Prepare an idle connection to be handed to a borrower. If the connection
+// has expired or fails a health check it is closed and nil is returned so
+// that the borrower dials a new connection in its place.
+func (p *pool) checkout(entry idleConn) Conn {
+	now := p.clock.Now()
+
+	if p.expired(entry, now) {
+		p.logger.Debug("Closing expired connection")
+		atomic.AddUint64(&p.counters.reaped, 1)
+		p.closeConn(entry.conn)
+		return nil
+	}
+
+	// A connection which has been idle for a while may have been silently
+	// dropped by the remote end (or by something in between). Ensure that
+	// it is still usable before handing it to the borrower.
+
+	if p.checkIdle > 0 && now.Sub(entry.released) >= p.checkIdle {
+		if _, err := entry.conn.Do("PING"); err != nil {
+			p.logger.Warn("Closing connection which failed a health check", "error", err)
+			atomic.AddUint64(&p.counters.errorClosed, 1)
+			p.closeConn(entry.conn)
+			p.events.emit(Event{Type: ConnectionDiscarded, Err: err})
+			return nil
+		}
+	}
+
+	p.track(entry.conn, entry.created)
+	return entry.conn
+}
+
+// Stop tracking a borrowed connection which the borrower has given up on,
+// close it unless it is already closed, and return a nil placeholder to
+// the pool so that a new connection is dialed in its place.
+func (p *pool) forget(conn Conn, closed bool) {
+	p.createdMutex.Lock()
+	_, forced := p.forced[conn]
+	delete(p.created, conn)
+	delete(p.forced, conn)
+	p.createdMutex.Unlock()
+
+	if !closed && !forced {
+		if err := conn.Close(); err != nil {
+			p.logger.Warn("Could not close connection", "error", err)
+		}
+	}
+
+	p.release(nil)
+}
+
+// Record the time at which a borrowed connection was dialed.
+func (p *pool) track(conn Conn, created time.Time) {
+	p.createdMutex.Lock()
+	p.created[conn] = created
+	p.createdMutex.Unlock()
+}
+
+// Determine if the connection has been idle for too long or has exceeded
+// its maximum lifetime.
+func (p *pool) expired(entry idleConn, now time.Time) bool {
+	if p.maxLifetime > 0 && now.Sub(entry.created) >= p.maxLifetime {
+		return true
+	}
+
+	return p.maxIdleTime > 0 && now.Sub(entry.released) >= p.maxIdleTime
+}
+
+// Close a borrowed connection and return a nil placeholder to the pool.
+func (p *pool) discard(conn Conn) {
+	p.closeConn(conn)
+	p.putNil()
+}
+
+func (p *pool) closeConn(conn Conn) {
+	atomic.AddInt64(&p.counters.open, -1)
+
+	if err := conn.Close(); err != nil {
+		p.logger.Warn("Could not close connection", "error", err)
+	}
+}
+
+// Return the interval at which idle connections are checked for expiry
+// and replenished, or zero if no background maintenance is necessary.
+func (p *pool) maintenanceInterval() time.Duration {
+	interval := p.maxIdleTime
+	if interval <= 0 || (p.maxLifetime > 0 && p.maxLifetime < interval) {
+		interval = p.maxLifetime
+	}
+
+	if interval > 0 {
+		interval /= 2
+	} else if p.minIdleConns > 0 {
+		interval = defaultMaintenanceInterval
+	}
+
+	if p.adaptive != nil && (interval <= 0 || p.adaptive.interval < interval) {
+		interval = p.adaptive.interval
+	}
+
+	if p.leaks != nil && (interval <= 0 || p.leaks.threshold/2 < interval) {
+		interval = p.leaks.threshold / 2
+	}
+
+	return interval
+}
+
+// Dial the minimum number of idle connections, then periodically close
+// expired idle connections and replace them until the pool is closed. If
+// the pool is adaptive, it is also resized on each interval.
+func (p *pool) maintain(interval time.Duration) {
+	defer p.wg.Done()
+
+	p.fill()
+
+	for {
+		select {
+		case <-p.clock.After(interval):
+		case <-p.halt:
+			return
+		}
+
+		p.reap()
+
+		if p.adaptive != nil {
+			p.adapt()
+		}
+
+		if p.leaks != nil {
+			p.leaks.check()
+		}
+
+		p.fill()
+	}
+}
+
+// Dial new connections until the pool holds the minimum number of idle
+// connections. This stops early if every connection is borrowed, if a
+// dial fails, or if the pool is closed.
+func (p *pool) fill() {
+	for {
+		select {
+		case <-p.halt:
+			return
+		default:
+		}
+
+		p.mutex.Lock()
+
+		if len(p.idle) >= p.minIdleConns || p.nils == 0 {
+			p.mutex.Unlock()
+			return
+		}
+
+		p.nils--
+		p.borrowed++
+		p.mutex.Unlock()
+
+		conn, err := p.dial()
+		if err != nil {
+			return
+		}
+
+		p.release(conn)
+	}
+}
+
+// Close each idle connection which has expired and replace it with a nil
+// placeholder. Connections which are borrowed concurrently are checked on
+// borrow.
+func (p *pool) reap() {
+	now := p.clock.Now()
+
+	p.mutex.Lock()
+
+	var (
+		idle    = make([]idleConn, 0, len(p.idle))
+		expired []idleConn
+	)
+
+	for _, entry := range p.idle {
+		if p.expired(entry, now) {
+			expired = append(expired, entry)
+		} else {
+			idle = append(idle, entry)
+		}
+	}
+
+	p.idle = idle
+	p.nils += len(expired)
+
+	if len(expired) > 0 {
+		p.dispatch()
+	}
+
+	p.mutex.Unlock()
+
+	for _, entry := range expired {
+		p.logger.Debug("Closing expired idle connection")
+		atomic.AddUint64(&p.counters.reaped, 1)
+		p.closeConn(entry.conn)
+	}
 }
 
 var blockingChan = make(chan time.Time)
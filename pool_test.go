@@ -2,6 +2,9 @@ package deepjoy
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
 	"time"
 
 	"github.com/aphistic/sweet"
@@ -82,7 +85,7 @@ func (s *PoolSuite) TestPoolDialOnNilConnectionAfterRelease(t sweet.T) {
 	Expect(dials).To(Equal(20))
 
 	for i := 0; i < 10; i++ {
-		pool.Release(nil)
+		pool.Discard(conn)
 	}
 
 	for i := 0; i < 10; i++ {
@@ -109,12 +112,14 @@ func (s *PoolSuite) TestClose(t sweet.T) {
 		)
 	)
 
+	borrowed := []Conn{}
 	for i := 0; i < 15; i++ {
-		pool.Borrow()
+		c, _ := pool.Borrow()
+		borrowed = append(borrowed, c)
 	}
 
 	for i := 0; i < 5; i++ {
-		pool.Release(nil)
+		pool.Discard(borrowed[i])
 	}
 
 	for i := 0; i < 10; i++ {
@@ -163,6 +168,176 @@ func (s *PoolSuite) TestCloseBlocks(t sweet.T) {
 	Eventually(sync).Should(BeClosed())
 }
 
+func (s *PoolSuite) TestCloseContext(t sweet.T) {
+	var (
+		conn   = mocks.NewMockConn()
+		result = make(chan error)
+		pool   = NewPool(
+			func() (Conn, error) { return conn, nil },
+			1,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	c, _ := pool.Borrow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	go func() {
+		result <- pool.CloseContext(ctx)
+	}()
+
+	// New borrows are rejected while the pool drains
+	Eventually(func() error { _, err := pool.BorrowTimeoutErr(0); return err }).Should(Equal(ErrPoolClosed))
+	Consistently(result).ShouldNot(Receive())
+
+	pool.Release(c)
+	Eventually(result).Should(Receive(BeNil()))
+	Expect(conn.CloseFunc).To(BeCalledOnce())
+}
+
+func (s *PoolSuite) TestCloseConcurrently(t sweet.T) {
+	var (
+		closed = make(chan struct{})
+		result = make(chan error)
+		pool   = NewPool(testDial, 1, NilLogger, noopBreakerFunc, nil)
+	)
+
+	c, _ := pool.Borrow()
+
+	go func() {
+		pool.Close()
+		close(closed)
+	}()
+
+	Eventually(func() error { _, err := pool.BorrowTimeoutErr(0); return err }).Should(Equal(ErrPoolClosed))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	go func() {
+		result <- pool.CloseContext(ctx)
+	}()
+
+	Consistently(result).ShouldNot(Receive())
+
+	// Both calls return once the connection is released
+	pool.Release(c)
+	Eventually(result).Should(Receive(BeNil()))
+	Eventually(closed).Should(BeClosed())
+}
+
+func (s *PoolSuite) TestCloseContextDeadline(t sweet.T) {
+	var (
+		conn = mocks.NewMockConn()
+		pool = NewPool(
+			func() (Conn, error) { return conn, nil },
+			2,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	c, _ := pool.Borrow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Borrowed connections are closed after the deadline
+	Expect(pool.CloseContext(ctx)).To(Equal(context.Canceled))
+	Expect(conn.CloseFunc).To(BeCalledOnce())
+
+	// Late releases do not close the connection a second time
+	pool.Release(c)
+	Expect(conn.CloseFunc).To(BeCalledOnce())
+	Expect(pool.Stats().Open).To(Equal(0))
+}
+
+func (s *PoolSuite) TestDiscard(t sweet.T) {
+	var (
+		conn1 = mocks.NewMockConn()
+		conn2 = mocks.NewMockConn()
+		conns = []Conn{conn1, conn2}
+		dial  = func() (Conn, error) { conn := conns[0]; conns = conns[1:]; return conn, nil }
+		pool  = NewPool(dial, 2, NilLogger, noopBreakerFunc, nil)
+	)
+
+	c1, _ := pool.Borrow()
+	c2, _ := pool.Borrow()
+
+	// Discarded connections are closed and no longer tracked
+	pool.Discard(c1)
+	Expect(conn1.CloseFunc).To(BeCalledOnce())
+	Expect(trackedConns(pool)).To(Equal(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Discarding a connection closed after the deadline does not close
+	// it a second time
+	Expect(pool.CloseContext(ctx)).To(Equal(context.Canceled))
+	pool.Discard(c2)
+	Expect(conn2.CloseFunc).To(BeCalledOnce())
+	Expect(trackedConns(pool)).To(Equal(0))
+	Expect(pool.Stats().Open).To(Equal(0))
+}
+
+func (s *PoolSuite) TestCloseWakesWaiters(t sweet.T) {
+	var (
+		result = make(chan error)
+		pool   = NewPool(
+			testDial,
+			1,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	c, _ := pool.Borrow()
+
+	go func() {
+		_, err := pool.BorrowErr()
+		result <- err
+	}()
+
+	Eventually(func() uint64 { return pool.Stats().BorrowWaits }).Should(Equal(uint64(1)))
+
+	go pool.Close()
+	Eventually(result).Should(Receive(Equal(ErrPoolClosed)))
+	pool.Release(c)
+}
+
+func (s *PoolSuite) TestReleaseAfterClose(t sweet.T) {
+	var (
+		conn = mocks.NewMockConn()
+		pool = NewPool(
+			func() (Conn, error) { return conn, nil },
+			2,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	c, _ := pool.Borrow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pool.CloseContext(ctx)
+	Expect(func() { pool.Release(c) }).NotTo(Panic())
+
+	// A second close does not panic
+	Expect(func() { pool.Close() }).NotTo(Panic())
+
+	_, err := pool.BorrowErr()
+	Expect(err).To(Equal(ErrPoolClosed))
+}
+
 func (s *PoolSuite) TestBorrowFavorsNonNil(t sweet.T) {
 	var (
 		dials = 0
@@ -208,8 +383,9 @@ func (s *PoolSuite) TestPoolCapacity(t sweet.T) {
 		)
 	)
 
+	var c Conn
 	for i := 0; i < 20; i++ {
-		pool.Borrow()
+		c, _ = pool.Borrow()
 	}
 
 	go func() {
@@ -218,10 +394,34 @@ func (s *PoolSuite) TestPoolCapacity(t sweet.T) {
 	}()
 
 	Consistently(sync).ShouldNot(BeClosed())
-	pool.Release(nil)
+	pool.Discard(c)
 	Eventually(sync).Should(BeClosed())
 }
 
+func (s *PoolSuite) TestReleaseNil(t sweet.T) {
+	var (
+		logger, messages = makeRecordingLogger()
+		pool             = NewPool(
+			testDial,
+			1,
+			logger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	defer pool.Close()
+
+	pool.Borrow()
+	pool.Release(nil)
+	Eventually(messages).Should(Receive(ContainSubstring("use Discard instead")))
+
+	// The place of the connection is still freed
+	c, ok := pool.BorrowTimeout(time.Second)
+	Expect(ok).To(BeTrue())
+	pool.Release(c)
+}
+
 func (s *PoolSuite) TestBorrowTimeout(t sweet.T) {
 	var (
 		result = make(chan bool)
@@ -282,6 +482,883 @@ func (s *PoolSuite) TestCircuitBreaker(t sweet.T) {
 	}
 }
 
+func (s *PoolSuite) TestMaxIdleTime(t sweet.T) {
+	var (
+		clock  = glock.NewMockClock()
+		dials  = 0
+		closed = make(chan struct{}, 3)
+		dial   = func() (Conn, error) {
+			dials++
+			conn := mocks.NewMockConn()
+			conn.CloseFunc.SetDefaultHook(func() error {
+				closed <- struct{}{}
+				return nil
+			})
+
+			return conn, nil
+		}
+
+		pool = NewPool(
+			dial,
+			20,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithMaxIdleTime(time.Minute),
+		)
+	)
+
+	defer pool.Close()
+
+	borrowed := []Conn{}
+	for i := 0; i < 3; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+
+	// Not yet idle for long enough
+	clock.BlockingAdvance(time.Second * 30)
+	Consistently(closed).ShouldNot(Receive())
+
+	clock.BlockingAdvance(time.Second * 30)
+
+	for i := 0; i < 3; i++ {
+		Eventually(closed).Should(Receive())
+	}
+
+	// Expired connections are replaced by nil values
+	conn, _ := pool.Borrow()
+	Expect(dials).To(Equal(4))
+	pool.Release(conn)
+}
+
+func (s *PoolSuite) TestMaxIdleTimeSteadyLoad(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		pool  = NewPool(
+			testDial,
+			20,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithMaxIdleTime(time.Minute),
+		)
+	)
+
+	defer pool.Close()
+
+	// Open connections during a spike
+	borrowed := []Conn{}
+	for i := 0; i < 5; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+
+	// A steady load reuses the same connection
+	for i := 0; i < 12; i++ {
+		conn, _ := pool.Borrow()
+		pool.Release(conn)
+		clock.BlockingAdvance(time.Second * 10)
+	}
+
+	Eventually(func() int { return pool.Stats().Open }).Should(Equal(1))
+	Expect(pool.Stats().Reaped).To(Equal(uint64(4)))
+}
+
+func (s *PoolSuite) TestMaxIdleTimeOnBorrow(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		dials = 0
+		conn  = mocks.NewMockConn()
+		pool  = &pool{
+			dialer:      func() (Conn, error) { dials++; return conn, nil },
+			capacity:    1,
+			logger:      NilLogger,
+			breakerFunc: noopBreakerFunc,
+			clock:       clock,
+			maxIdleTime: time.Minute,
+			created:     map[Conn]time.Time{},
+			nils:        1,
+		}
+	)
+
+	// Construct the pool without a reaper
+
+	c, _ := pool.Borrow()
+	pool.Release(c)
+	clock.Advance(time.Minute)
+
+	c, _ = pool.Borrow()
+	Expect(c).To(BeIdenticalTo(conn))
+	Expect(conn.CloseFunc).To(BeCalledOnce())
+	Expect(dials).To(Equal(2))
+}
+
+func (s *PoolSuite) TestMaxConnLifetime(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		dials = 0
+		conn  = mocks.NewMockConn()
+		pool  = NewPool(
+			func() (Conn, error) { dials++; return conn, nil },
+			20,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithMaxConnLifetime(time.Hour),
+		)
+	)
+
+	defer pool.Close()
+
+	c, _ := pool.Borrow()
+	pool.Release(c)
+
+	c, _ = pool.Borrow()
+	Expect(dials).To(Equal(1))
+
+	// Released after the lifetime elapsed
+	clock.Advance(time.Hour)
+	pool.Release(c)
+	Expect(conn.CloseFunc).To(BeCalledOnce())
+
+	c, _ = pool.Borrow()
+	Expect(dials).To(Equal(2))
+	pool.Release(c)
+}
+
+func (s *PoolSuite) TestMaxIdleConns(t sweet.T) {
+	var (
+		dials = 0
+		conns = []*mocks.MockConn{}
+		dial  = func() (Conn, error) {
+			dials++
+			conn := mocks.NewMockConn()
+			conns = append(conns, conn)
+			return conn, nil
+		}
+
+		pool = NewPool(
+			dial,
+			5,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+			WithMaxIdleConns(2),
+		)
+	)
+
+	borrowed := []Conn{}
+	for i := 0; i < 5; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+
+	closed := 0
+	for _, conn := range conns {
+		closed += len(conn.CloseFunc.History())
+	}
+
+	Expect(closed).To(Equal(3))
+
+	for i := 0; i < 5; i++ {
+		pool.Borrow()
+	}
+
+	Expect(dials).To(Equal(8))
+}
+
+func (s *PoolSuite) TestBorrowHealthCheck(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		dials = 0
+		conn  = mocks.NewMockConn()
+		pool  = NewPool(
+			func() (Conn, error) { dials++; return conn, nil },
+			20,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithBorrowHealthCheck(time.Minute),
+		)
+	)
+
+	c, _ := pool.Borrow()
+	pool.Release(c)
+
+	// Recently used, no health check
+	c, _ = pool.Borrow()
+	Expect(conn.DoFunc).NotTo(BeCalled())
+	pool.Release(c)
+
+	clock.Advance(time.Minute)
+
+	c, _ = pool.Borrow()
+	Expect(c).To(BeIdenticalTo(conn))
+	Expect(conn.DoFunc).To(BeCalledOnceWith("PING"))
+	Expect(conn.CloseFunc).NotTo(BeCalled())
+	Expect(dials).To(Equal(1))
+}
+
+func (s *PoolSuite) TestBorrowHealthCheckFailure(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		conn1 = mocks.NewMockConn()
+		conn2 = mocks.NewMockConn()
+		conns = []Conn{conn1, conn2}
+		pool  = NewPool(
+			func() (Conn, error) {
+				conn := conns[0]
+				conns = conns[1:]
+				return conn, nil
+			},
+			20,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithBorrowHealthCheck(time.Minute),
+		)
+	)
+
+	conn1.DoFunc.SetDefaultReturn(nil, connErr{io.EOF})
+
+	c, _ := pool.Borrow()
+	pool.Release(c)
+	clock.Advance(time.Minute * 5)
+
+	// Replaced transparently
+	c, ok := pool.Borrow()
+	Expect(ok).To(BeTrue())
+	Expect(c).To(BeIdenticalTo(conn2))
+	Expect(conn1.CloseFunc).To(BeCalledOnce())
+}
+
+func (s *PoolSuite) TestMinIdleConns(t sweet.T) {
+	var (
+		clock  = glock.NewMockClock()
+		dialed = make(chan struct{}, 10)
+		pool   = NewPool(
+			func() (Conn, error) {
+				dialed <- struct{}{}
+				return mocks.NewMockConn(), nil
+			},
+			5,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithMinIdleConns(3),
+		)
+	)
+
+	defer pool.Close()
+
+	// Dialed in the background on startup
+	for i := 0; i < 3; i++ {
+		Eventually(dialed).Should(Receive())
+	}
+
+	borrowed := []Conn{}
+	for i := 0; i < 3; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	Consistently(dialed).ShouldNot(Receive())
+
+	// Replenished up to the remaining capacity
+	clock.BlockingAdvance(defaultMaintenanceInterval)
+
+	for i := 0; i < 2; i++ {
+		Eventually(dialed).Should(Receive())
+	}
+
+	Consistently(dialed).ShouldNot(Receive())
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+}
+
+func (s *PoolSuite) TestStats(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		pool  = NewPool(
+			testDial,
+			2,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+		)
+	)
+
+	c1, _ := pool.Borrow()
+	c2, _ := pool.Borrow()
+	pool.Release(c1)
+
+	stats := pool.Stats()
+	Expect(stats.Capacity).To(Equal(2))
+	Expect(stats.Open).To(Equal(2))
+	Expect(stats.Idle).To(Equal(1))
+	Expect(stats.InUse).To(Equal(1))
+	Expect(stats.Dials).To(Equal(uint64(2)))
+
+	// Connection discarded by the borrower after an error
+	pool.Discard(c2)
+	c1, _ = pool.Borrow()
+	c2, _ = pool.Borrow()
+
+	stats = pool.Stats()
+	Expect(stats.Open).To(Equal(2))
+	Expect(stats.ErrorClosed).To(Equal(uint64(1)))
+	Expect(stats.Dials).To(Equal(uint64(3)))
+
+	result := make(chan bool)
+	go func() {
+		_, ok := pool.BorrowTimeout(time.Second * 10)
+		result <- ok
+	}()
+
+	clock.BlockingAdvance(time.Second * 10)
+	Eventually(result).Should(Receive(BeFalse()))
+
+	stats = pool.Stats()
+	Expect(stats.BorrowWaits).To(Equal(uint64(1)))
+	Expect(stats.BorrowTimeouts).To(Equal(uint64(1)))
+	Expect(stats.WaitDuration).To(Equal(time.Second * 10))
+
+	pool.Release(c1)
+	pool.Release(c2)
+}
+
+func (s *PoolSuite) TestStatsDialFailures(t sweet.T) {
+	var (
+		count       = 2
+		breakerFunc = func(f overcurrent.BreakerFunc) error {
+			if count <= 0 {
+				return overcurrent.ErrCircuitOpen
+			}
+
+			count--
+			return f(context.Background())
+		}
+
+		pool = NewPool(
+			func() (Conn, error) { return nil, fmt.Errorf("utoh") },
+			20,
+			NilLogger,
+			breakerFunc,
+			nil,
+		)
+	)
+
+	for i := 0; i < 5; i++ {
+		pool.Borrow()
+	}
+
+	stats := pool.Stats()
+	Expect(stats.Open).To(Equal(0))
+	Expect(stats.Dials).To(Equal(uint64(2)))
+	Expect(stats.DialFailures).To(Equal(uint64(2)))
+	Expect(stats.BreakerRejections).To(Equal(uint64(3)))
+}
+
+func (s *PoolSuite) TestStatsReaped(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		pool  = NewPool(
+			testDial,
+			20,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithMaxConnLifetime(time.Minute),
+			WithMaxIdleConns(1),
+		)
+	)
+
+	defer pool.Close()
+
+	c1, _ := pool.Borrow()
+	c2, _ := pool.Borrow()
+	pool.Release(c1)
+	pool.Release(c2)
+
+	stats := pool.Stats()
+	Expect(stats.Open).To(Equal(1))
+	Expect(stats.Reaped).To(Equal(uint64(1)))
+}
+
+func (s *PoolSuite) TestBorrowFIFO(t sweet.T) {
+	var (
+		pool = NewPool(
+			testDial,
+			1,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	defer pool.Close()
+
+	c, _ := pool.Borrow()
+	order := make(chan int, 5)
+
+	for i := 0; i < 5; i++ {
+		go func(i int) {
+			conn, _ := pool.Borrow()
+			order <- i
+			pool.Release(conn)
+		}(i)
+
+		// Ensure each borrower joins the queue before the next
+		Eventually(func() uint64 { return pool.Stats().BorrowWaits }).Should(Equal(uint64(i + 1)))
+	}
+
+	pool.Release(c)
+
+	for i := 0; i < 5; i++ {
+		Eventually(order).Should(Receive(Equal(i)))
+	}
+}
+
+func (s *PoolSuite) TestMaxWaiters(t sweet.T) {
+	var (
+		pool = NewPool(
+			testDial,
+			1,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+			WithMaxWaiters(1),
+		)
+	)
+
+	defer pool.Close()
+
+	c1, _ := pool.Borrow()
+
+	borrowed := make(chan Conn)
+	go func() {
+		conn, _ := pool.Borrow()
+		borrowed <- conn
+	}()
+
+	Eventually(func() uint64 { return pool.Stats().BorrowWaits }).Should(Equal(uint64(1)))
+
+	// Queue is full
+	_, err := pool.BorrowErr()
+	Expect(err).To(Equal(ErrPoolExhausted))
+	_, err = pool.BorrowTimeoutErr(time.Second)
+	Expect(err).To(Equal(ErrPoolExhausted))
+
+	pool.Release(c1)
+
+	var c2 Conn
+	Eventually(borrowed).Should(Receive(&c2))
+	Expect(c2).To(BeIdenticalTo(c1))
+	pool.Release(c2)
+}
+
+func (s *PoolSuite) TestMaxConcurrentDials(t sweet.T) {
+	var (
+		pending = int32(0)
+		unblock = make(chan struct{})
+		dial    = func() (Conn, error) {
+			atomic.AddInt32(&pending, 1)
+			<-unblock
+			return mocks.NewMockConn(), nil
+		}
+
+		pool = NewPool(
+			dial,
+			3,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+			WithMaxConcurrentDials(2),
+		)
+	)
+
+	borrowed := make(chan Conn, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			conn, _ := pool.Borrow()
+			borrowed <- conn
+		}()
+	}
+
+	Eventually(func() int32 { return atomic.LoadInt32(&pending) }).Should(Equal(int32(2)))
+	Consistently(func() int32 { return atomic.LoadInt32(&pending) }).Should(Equal(int32(2)))
+	close(unblock)
+
+	for i := 0; i < 3; i++ {
+		var conn Conn
+		Eventually(borrowed).Should(Receive(&conn))
+		Expect(conn).NotTo(BeNil())
+		pool.Release(conn)
+	}
+
+	pool.Close()
+}
+
+func (s *PoolSuite) TestReleaseDuringDial(t sweet.T) {
+	var (
+		conn    = mocks.NewMockConn()
+		blocked = int32(0)
+		unblock = make(chan struct{})
+		dial    = func() (Conn, error) {
+			if atomic.LoadInt32(&blocked) == 1 {
+				<-unblock
+				return mocks.NewMockConn(), nil
+			}
+
+			return conn, nil
+		}
+
+		pool = NewPool(
+			dial,
+			2,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	c1, _ := pool.Borrow()
+	atomic.StoreInt32(&blocked, 1)
+
+	borrowed := make(chan Conn)
+	go func() {
+		conn, _ := pool.Borrow()
+		borrowed <- conn
+	}()
+
+	Eventually(func() uint64 { return pool.Stats().Dials }).Should(Equal(uint64(2)))
+
+	// The borrower takes the released connection before its own dial completes
+	pool.Release(c1)
+	Eventually(borrowed).Should(Receive(BeIdenticalTo(conn)))
+
+	// The dialed connection is released to the pool
+	close(unblock)
+	Eventually(func() int { return pool.Stats().Idle }).Should(Equal(1))
+
+	pool.Release(conn)
+	pool.Close()
+}
+
+func (s *PoolSuite) TestDialFailureNotShared(t sweet.T) {
+	var (
+		dials   = int32(0)
+		unblock = make(chan struct{})
+		dial    = func() (Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			<-unblock
+			return nil, fmt.Errorf("utoh")
+		}
+
+		pool = NewPool(
+			dial,
+			3,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+			WithMaxConcurrentDials(1),
+		)
+	)
+
+	defer pool.Close()
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := pool.BorrowErr()
+			errs <- err
+		}()
+	}
+
+	Eventually(func() int32 { return atomic.LoadInt32(&dials) }).Should(Equal(int32(1)))
+	Consistently(errs).ShouldNot(Receive())
+	close(unblock)
+
+	// Borrowers waiting to dial make their own attempt
+	for i := 0; i < 3; i++ {
+		Eventually(errs).Should(Receive(Equal(ErrNoConnection)))
+	}
+
+	Expect(atomic.LoadInt32(&dials)).To(Equal(int32(3)))
+}
+
+func (s *PoolSuite) TestSharedBreakerRejection(t sweet.T) {
+	var (
+		calls       = int32(0)
+		unblock     = make(chan struct{})
+		breakerFunc = func(f overcurrent.BreakerFunc) error {
+			atomic.AddInt32(&calls, 1)
+			<-unblock
+			return overcurrent.ErrCircuitOpen
+		}
+
+		pool = NewPool(
+			testDial,
+			3,
+			NilLogger,
+			breakerFunc,
+			nil,
+			WithMaxConcurrentDials(1),
+		)
+	)
+
+	defer pool.Close()
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := pool.BorrowErr()
+			errs <- err
+		}()
+	}
+
+	Eventually(func() int32 { return atomic.LoadInt32(&calls) }).Should(Equal(int32(1)))
+	Consistently(errs).ShouldNot(Receive())
+	close(unblock)
+
+	// Borrowers waiting to dial share the rejection of the first dial
+	for i := 0; i < 3; i++ {
+		Eventually(errs).Should(Receive(Equal(ErrNoConnection)))
+	}
+
+	Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+}
+
+func (s *PoolSuite) TestResizeGrow(t sweet.T) {
+	var (
+		pool = NewPool(
+			testDial,
+			1,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	c1, _ := pool.Borrow()
+
+	borrowed := make(chan Conn)
+	go func() {
+		conn, _ := pool.Borrow()
+		borrowed <- conn
+	}()
+
+	Eventually(func() uint64 { return pool.Stats().BorrowWaits }).Should(Equal(uint64(1)))
+	Consistently(borrowed).ShouldNot(Receive())
+
+	pool.Resize(2)
+
+	var c2 Conn
+	Eventually(borrowed).Should(Receive(&c2))
+	Expect(c2).NotTo(BeNil())
+	Expect(pool.Stats().Capacity).To(Equal(2))
+
+	pool.Release(c1)
+	pool.Release(c2)
+	pool.Close()
+}
+
+func (s *PoolSuite) TestResizeShrink(t sweet.T) {
+	var (
+		conns = []*mocks.MockConn{}
+		dial  = func() (Conn, error) {
+			conn := mocks.NewMockConn()
+			conns = append(conns, conn)
+			return conn, nil
+		}
+
+		pool = NewPool(
+			dial,
+			3,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	defer pool.Close()
+
+	borrowed := []Conn{}
+	for i := 0; i < 3; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+
+	pool.Resize(1)
+
+	// The oldest idle connections are closed
+	Expect(conns[0].CloseFunc).To(BeCalledOnce())
+	Expect(conns[1].CloseFunc).To(BeCalledOnce())
+	Expect(conns[2].CloseFunc).NotTo(BeCalled())
+
+	stats := pool.Stats()
+	Expect(stats.Capacity).To(Equal(1))
+	Expect(stats.Open).To(Equal(1))
+	Expect(stats.Idle).To(Equal(1))
+	Expect(stats.Reaped).To(Equal(uint64(2)))
+}
+
+func (s *PoolSuite) TestResizeShrinkBorrowed(t sweet.T) {
+	var (
+		conns = []*mocks.MockConn{}
+		dial  = func() (Conn, error) {
+			conn := mocks.NewMockConn()
+			conns = append(conns, conn)
+			return conn, nil
+		}
+
+		pool = NewPool(
+			dial,
+			3,
+			NilLogger,
+			noopBreakerFunc,
+			nil,
+		)
+	)
+
+	defer pool.Close()
+
+	borrowed := []Conn{}
+	for i := 0; i < 3; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	pool.Resize(1)
+
+	// Borrowed connections are not interrupted
+	stats := pool.Stats()
+	Expect(stats.Capacity).To(Equal(1))
+	Expect(stats.InUse).To(Equal(3))
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+
+	// Connections in excess of the capacity are closed on release
+	Expect(conns[0].CloseFunc).To(BeCalledOnce())
+	Expect(conns[1].CloseFunc).To(BeCalledOnce())
+	Expect(conns[2].CloseFunc).NotTo(BeCalled())
+
+	c1, _ := pool.Borrow()
+	Expect(c1).To(BeIdenticalTo(conns[2]))
+
+	_, ok := pool.BorrowTimeout(time.Millisecond * 10)
+	Expect(ok).To(BeFalse())
+	pool.Release(c1)
+}
+
+func (s *PoolSuite) TestAdaptiveGrow(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		pool  = NewPool(
+			testDial,
+			2,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithAdaptivePoolCapacity(1, 4, time.Millisecond*100),
+		)
+	)
+
+	c1, _ := pool.Borrow()
+	c2, _ := pool.Borrow()
+
+	borrowed := make(chan Conn)
+	go func() {
+		conn, _ := pool.Borrow()
+		borrowed <- conn
+	}()
+
+	Eventually(func() uint64 { return pool.Stats().BorrowWaits }).Should(Equal(uint64(1)))
+
+	// Wait for longer than the target
+	clock.BlockingAdvance(time.Millisecond * 500)
+	pool.Release(c1)
+	c3 := <-borrowed
+
+	clock.BlockingAdvance(time.Millisecond * 500)
+	Eventually(func() int { return pool.Stats().Capacity }).Should(Equal(3))
+
+	pool.Release(c2)
+	pool.Release(c3)
+	pool.Close()
+}
+
+func (s *PoolSuite) TestAdaptiveShrink(t sweet.T) {
+	var (
+		clock = glock.NewMockClock()
+		pool  = NewPool(
+			testDial,
+			4,
+			NilLogger,
+			noopBreakerFunc,
+			clock,
+			WithAdaptivePoolCapacity(2, 4, time.Millisecond*100),
+			WithAdaptiveInterval(time.Second, time.Second*5),
+		)
+	)
+
+	defer pool.Close()
+
+	borrowed := []Conn{}
+	for i := 0; i < 3; i++ {
+		conn, _ := pool.Borrow()
+		borrowed = append(borrowed, conn)
+	}
+
+	for _, conn := range borrowed {
+		pool.Release(conn)
+	}
+
+	// Connections have not sat idle for the minimum idle time
+	for i := 0; i < 4; i++ {
+		clock.BlockingAdvance(time.Second)
+	}
+
+	Consistently(func() int { return pool.Stats().Capacity }, time.Millisecond*50).Should(Equal(4))
+
+	// Shrink one connection at a time
+	clock.BlockingAdvance(time.Second)
+	Eventually(func() int { return pool.Stats().Capacity }).Should(Equal(3))
+
+	// Wait for more quiet intervals before shrinking again
+	clock.BlockingAdvance(time.Second)
+	clock.BlockingAdvance(time.Second)
+	Consistently(func() int { return pool.Stats().Capacity }, time.Millisecond*50).Should(Equal(3))
+
+	clock.BlockingAdvance(time.Second)
+	Eventually(func() int { return pool.Stats().Capacity }).Should(Equal(2))
+
+	stats := pool.Stats()
+	Expect(stats.Idle).To(Equal(2))
+	Expect(stats.Reaped).To(Equal(uint64(1)))
+}
+
 func testDial() (Conn, error) {
 	return mocks.NewMockConn(), nil
 }
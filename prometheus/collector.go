@@ -0,0 +1,165 @@
+// Package prometheus provides a deepjoy metrics sink which is exposed as a
+// Prometheus collector. It is a separate module so that clients which do not
+// use Prometheus do not depend on its client library.
+package prometheus
+
+import (
+	"sync"
+
+	"github.com/efritz/deepjoy"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector receives measurements from a deepjoy client and exposes them
+// to Prometheus. Register it with a Prometheus registry and pass it to the
+// client with deepjoy.WithMetrics. A single collector may be shared by
+// several clients.
+type Collector struct {
+	namespace  string
+	buckets    []float64
+	counters   map[string]*prom.CounterVec
+	gauges     map[string]*prom.GaugeVec
+	histograms map[string]*prom.HistogramVec
+	mutex      sync.RWMutex
+}
+
+var (
+	_ deepjoy.Metrics = &Collector{}
+	_ prom.Collector  = &Collector{}
+)
+
+// labelNames are the names of the labels attached to every metric.
+var labelNames = []string{"command", "role", "address", "outcome"}
+
+// NewCollector creates a collector whose metric names are prefixed with the
+// given namespace. Latency histograms use deepjoy.DefaultLatencyBuckets.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		namespace:  namespace,
+		buckets:    append([]float64(nil), deepjoy.DefaultLatencyBuckets...),
+		counters:   map[string]*prom.CounterVec{},
+		gauges:     map[string]*prom.GaugeVec{},
+		histograms: map[string]*prom.HistogramVec{},
+	}
+}
+
+// IncCounter increments the named counter by one.
+func (c *Collector) IncCounter(name string, labels deepjoy.MetricLabels) {
+	c.counter(name).WithLabelValues(labelValues(labels)...).Inc()
+}
+
+// SetGauge sets the current value of the named gauge.
+func (c *Collector) SetGauge(name string, labels deepjoy.MetricLabels, value float64) {
+	c.gauge(name).WithLabelValues(labelValues(labels)...).Set(value)
+}
+
+// ObserveHistogram adds an observation to the named histogram.
+func (c *Collector) ObserveHistogram(name string, labels deepjoy.MetricLabels, value float64) {
+	c.histogram(name).WithLabelValues(labelValues(labels)...).Observe(value)
+}
+
+// Describe sends no descriptors, as metrics are created as the client reports
+// them. This makes the collector unchecked by the registry.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+}
+
+// Collect sends every metric reported so far.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, counter := range c.counters {
+		counter.Collect(ch)
+	}
+
+	for _, gauge := range c.gauges {
+		gauge.Collect(ch)
+	}
+
+	for _, histogram := range c.histograms {
+		histogram.Collect(ch)
+	}
+}
+
+func (c *Collector) counter(name string) *prom.CounterVec {
+	c.mutex.RLock()
+	counter, ok := c.counters[name]
+	c.mutex.RUnlock()
+
+	if ok {
+		return counter
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if counter, ok := c.counters[name]; ok {
+		return counter
+	}
+
+	counter = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: c.namespace,
+		Name:      name,
+		Help:      "Redis client counter " + name + ".",
+	}, labelNames)
+
+	c.counters[name] = counter
+	return counter
+}
+
+func (c *Collector) gauge(name string) *prom.GaugeVec {
+	c.mutex.RLock()
+	gauge, ok := c.gauges[name]
+	c.mutex.RUnlock()
+
+	if ok {
+		return gauge
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if gauge, ok := c.gauges[name]; ok {
+		return gauge
+	}
+
+	gauge = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: c.namespace,
+		Name:      name,
+		Help:      "Redis client gauge " + name + ".",
+	}, labelNames)
+
+	c.gauges[name] = gauge
+	return gauge
+}
+
+func (c *Collector) histogram(name string) *prom.HistogramVec {
+	c.mutex.RLock()
+	histogram, ok := c.histograms[name]
+	c.mutex.RUnlock()
+
+	if ok {
+		return histogram
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if histogram, ok := c.histograms[name]; ok {
+		return histogram
+	}
+
+	histogram = prom.NewHistogramVec(prom.HistogramOpts{
+		Namespace: c.namespace,
+		Name:      name,
+		Help:      "Redis client histogram " + name + ".",
+		Buckets:   c.buckets,
+	}, labelNames)
+
+	c.histograms[name] = histogram
+	return histogram
+}
+
+func labelValues(labels deepjoy.MetricLabels) []string {
+	return []string{labels.Command, labels.Role, labels.Address, labels.Outcome}
+}
@@ -0,0 +1,48 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/efritz/deepjoy"
+	. "github.com/onsi/gomega"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	var (
+		g         = NewGomegaWithT(t)
+		collector = NewCollector("redis")
+		registry  = prom.NewRegistry()
+		labels    = deepjoy.MetricLabels{Command: "GET", Role: deepjoy.RolePrimary, Address: "localhost:6379", Outcome: deepjoy.OutcomeSuccess}
+	)
+
+	g.Expect(registry.Register(collector)).To(BeNil())
+
+	collector.IncCounter(deepjoy.MetricCommands, labels)
+	collector.IncCounter(deepjoy.MetricCommands, labels)
+	collector.SetGauge(deepjoy.MetricPoolOpen, deepjoy.MetricLabels{Role: deepjoy.RolePrimary, Address: "localhost:6379"}, 3)
+	collector.ObserveHistogram(deepjoy.MetricCommandDuration, labels, 0.002)
+
+	families, err := registry.Gather()
+	g.Expect(err).To(BeNil())
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.Counter != nil:
+				values[family.GetName()] = metric.GetCounter().GetValue()
+			case metric.Gauge != nil:
+				values[family.GetName()] = metric.GetGauge().GetValue()
+			case metric.Histogram != nil:
+				values[family.GetName()] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	g.Expect(values).To(Equal(map[string]float64{
+		"redis_commands_total":           2,
+		"redis_pool_open_connections":    3,
+		"redis_command_duration_seconds": 1,
+	}))
+}
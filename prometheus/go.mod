@@ -0,0 +1,30 @@
+module github.com/efritz/deepjoy/prometheus
+
+go 1.27.1
+
+require (
+	github.com/efritz/deepjoy v0.0.0
+	github.com/onsi/gomega v1.4.3
+	github.com/prometheus/client_golang v1.20.5
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/bradhe/stopwatch v0.0.0-20180424000511-fd55e776a960 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/efritz/backoff v0.0.0-20181228195520-96f666d52d44 // indirect
+	github.com/efritz/glock v0.0.0-20180604185841-7e95e8b27a61 // indirect
+	github.com/efritz/overcurrent v0.0.0-20180604185922-c4abdcffd8c0 // indirect
+	github.com/gomodule/redigo v2.0.0+incompatible // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+replace github.com/efritz/deepjoy => ../
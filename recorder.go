@@ -0,0 +1,132 @@
+package deepjoy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// RecordingTracer is a Tracer which keeps every span in memory. It is
+	// intended for tests. Span identifiers follow the W3C trace context
+	// format, and spans started from a context containing a recorded span
+	// belong to the same trace.
+	RecordingTracer struct {
+		mutex sync.Mutex
+		spans []*RecordedSpan
+	}
+
+	// RecordedSpan is a span created by a RecordingTracer.
+	RecordedSpan struct {
+		Name       string
+		TraceID    string
+		SpanID     string
+		ParentID   string
+		Attributes map[string]interface{}
+		Err        error
+		Start      time.Time
+		End        time.Time
+		mutex      *sync.Mutex
+	}
+
+	recordingSpan struct {
+		span *RecordedSpan
+	}
+
+	recordedSpanKey struct{}
+)
+
+// NewRecordingTracer creates a new RecordingTracer.
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+// StartSpan starts a span as a child of the recorded span in the given
+// context, if any.
+func (t *RecordingTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	span := &RecordedSpan{
+		Name:       name,
+		TraceID:    randomID(16),
+		SpanID:     randomID(8),
+		Attributes: map[string]interface{}{},
+		Start:      time.Now(),
+		mutex:      &t.mutex,
+	}
+
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*RecordedSpan); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	}
+
+	t.mutex.Lock()
+	t.spans = append(t.spans, span)
+	t.mutex.Unlock()
+
+	return context.WithValue(ctx, recordedSpanKey{}, span), &recordingSpan{span: span}
+}
+
+// Spans returns a copy of every span started so far (ended or not) in the
+// order in which they were started.
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(t.spans))
+	for _, span := range t.spans {
+		copied := *span
+		copied.Attributes = make(map[string]interface{}, len(span.Attributes))
+		for key, value := range span.Attributes {
+			copied.Attributes[key] = value
+		}
+
+		spans = append(spans, copied)
+	}
+
+	return spans
+}
+
+// Reset discards every recorded span.
+func (t *RecordingTracer) Reset() {
+	t.mutex.Lock()
+	t.spans = nil
+	t.mutex.Unlock()
+}
+
+// Traceparent returns the span context in the format of a W3C traceparent
+// header.
+func (s RecordedSpan) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", s.TraceID, s.SpanID)
+}
+
+// Ended returns true if the span has ended.
+func (s RecordedSpan) Ended() bool {
+	return !s.End.IsZero()
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.span.mutex.Lock()
+	s.span.Attributes[key] = value
+	s.span.mutex.Unlock()
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.span.mutex.Lock()
+	s.span.Err = err
+	s.span.mutex.Unlock()
+}
+
+func (s *recordingSpan) End() {
+	s.span.mutex.Lock()
+	s.span.End = time.Now()
+	s.span.mutex.Unlock()
+}
+
+// Generate a random hex-encoded identifier of the given number of bytes.
+func randomID(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,99 @@
+package deepjoy
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// ArgRedactor formats the arguments of a command for a log entry or a
+	// span, replacing sensitive arguments with a placeholder. The returned
+	// slice has one element per argument.
+	ArgRedactor func(args []interface{}) []string
+
+	// redactor chooses the ArgRedactor of each command.
+	redactor struct {
+		rules map[string]ArgRedactor
+	}
+)
+
+// RedactedArg is the placeholder which replaces a redacted argument.
+const RedactedArg = "?"
+
+// defaultRedactionRules are the rules applied to commands whose arguments
+// may carry credentials. Commands without a rule keep only their first
+// argument (usually the key).
+var defaultRedactionRules = map[string]ArgRedactor{
+	"ACL":     RedactAll,
+	"AUTH":    RedactAll,
+	"CONFIG":  KeepArgs(1),
+	"HELLO":   RedactAll,
+	"MIGRATE": RedactAll,
+}
+
+// RedactAll is an ArgRedactor which redacts every argument.
+func RedactAll(args []interface{}) []string {
+	return KeepArgs(0)(args)
+}
+
+// KeepAll is an ArgRedactor which redacts no arguments.
+func KeepAll(args []interface{}) []string {
+	return KeepArgs(len(args))(args)
+}
+
+// KeepArgs creates an ArgRedactor which keeps the first n arguments and
+// redacts the remaining arguments.
+func KeepArgs(n int) ArgRedactor {
+	return func(args []interface{}) []string {
+		formatted := make([]string, 0, len(args))
+		for i, arg := range args {
+			if i < n {
+				formatted = append(formatted, formatArg(arg))
+			} else {
+				formatted = append(formatted, RedactedArg)
+			}
+		}
+
+		return formatted
+	}
+}
+
+// Create a redactor with the default rules overridden by the given rules.
+func newRedactor(rules map[string]ArgRedactor) redactor {
+	merged := make(map[string]ArgRedactor, len(defaultRedactionRules)+len(rules))
+	for command, rule := range defaultRedactionRules {
+		merged[command] = rule
+	}
+
+	for command, rule := range rules {
+		merged[strings.ToUpper(command)] = rule
+	}
+
+	return redactor{rules: merged}
+}
+
+// Format the arguments of the given (upper-case) command.
+func (r redactor) redact(command string, args []interface{}) []string {
+	if rule, ok := r.rules[command]; ok {
+		return rule(args)
+	}
+
+	return KeepArgs(1)(args)
+}
+
+// Format the command and its redacted arguments as a single string.
+func (r redactor) statement(command string, args []interface{}) string {
+	if len(args) == 0 {
+		return command
+	}
+
+	return command + " " + strings.Join(r.redact(command, args), " ")
+}
+
+func formatArg(arg interface{}) string {
+	if b, ok := arg.([]byte); ok {
+		return string(b)
+	}
+
+	return fmt.Sprint(arg)
+}
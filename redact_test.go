@@ -0,0 +1,30 @@
+package deepjoy
+
+import (
+	"github.com/aphistic/sweet"
+	. "github.com/onsi/gomega"
+)
+
+type RedactSuite struct{}
+
+func (s *RedactSuite) TestDefaultRules(t sweet.T) {
+	r := newRedactor(nil)
+	Expect(r.statement("GET", []interface{}{"foo"})).To(Equal("GET foo"))
+	Expect(r.statement("SET", []interface{}{"foo", []byte("bar"), "EX", 10})).To(Equal("SET foo ? ? ?"))
+	Expect(r.statement("AUTH", []interface{}{"user", "secret"})).To(Equal("AUTH ? ?"))
+	Expect(r.statement("CONFIG", []interface{}{"SET", "requirepass", "secret"})).To(Equal("CONFIG SET ? ?"))
+	Expect(r.statement("PING", nil)).To(Equal("PING"))
+}
+
+func (s *RedactSuite) TestOverrideRules(t sweet.T) {
+	r := newRedactor(map[string]ArgRedactor{
+		"set":  KeepArgs(2),
+		"GET":  RedactAll,
+		"AUTH": KeepAll,
+	})
+
+	Expect(r.redact("SET", []interface{}{"foo", "bar", "EX", 10})).To(Equal([]string{"foo", "bar", "?", "?"}))
+	Expect(r.redact("GET", []interface{}{"foo"})).To(Equal([]string{"?"}))
+	Expect(r.redact("AUTH", []interface{}{"secret"})).To(Equal([]string{"secret"}))
+	Expect(r.redact("HELLO", []interface{}{3, "AUTH", "user", "secret"})).To(Equal([]string{"?", "?", "?", "?"}))
+}
@@ -0,0 +1,563 @@
+package deepjoy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/efritz/backoff"
+	"github.com/efritz/glock"
+)
+
+type (
+	// ReplicaFallbackPolicy determines how reads are served by the client
+	// returned from ReadReplica when no read replica is healthy.
+	ReplicaFallbackPolicy int
+
+	// ReplicaEventType distinguishes replica ejections from recoveries.
+	ReplicaEventType int
+
+	// ReplicaEvent describes a read replica leaving or re-entering the
+	// rotation of the read replica client.
+	ReplicaEvent struct {
+		Type ReplicaEventType
+		Addr string
+		Err  error
+	}
+
+	// ReplicaEventHandler is invoked each time a read replica is ejected
+	// from or restored to the rotation. The handler is invoked from its
+	// own goroutine, in the order in which the events occurred.
+	ReplicaEventHandler func(ReplicaEvent)
+
+	replicaClient struct {
+		primary     *client
+		replicas    []*replica
+		policy      ReplicaFallbackPolicy
+		threshold   int
+		interval    time.Duration
+		maxLag      time.Duration
+		offsets     *offsetTracker
+		primaryRole string
+		handlerSub  *eventSubscription
+		handlerDone chan struct{}
+		backoff     backoff.Backoff
+		clock       glock.Clock
+		logger      LeveledLogger
+		next        uint64
+		mutex       sync.RWMutex
+		halt        chan struct{}
+		haltOnce    sync.Once
+		wg          sync.WaitGroup
+	}
+
+	boundedReplicaClient struct {
+		*replicaClient
+		filter replicaFilter
+	}
+
+	replica struct {
+		addr           string
+		client         *client
+		healthy        bool
+		failures       int
+		role           string
+		offset         int64
+		staleness      time.Duration
+		stalenessKnown bool
+	}
+
+	replicaFunc func(ctx context.Context, c *client) (interface{}, error)
+
+	// replicaFilter determines if a healthy replica can serve a read with
+	// a particular consistency requirement. Filters are invoked while the
+	// replica client's mutex is held.
+	replicaFilter func(r *replica) bool
+)
+
+const (
+	// FallbackToPrimary sends reads to the primary when no read replica
+	// is healthy. This is the default policy.
+	FallbackToPrimary ReplicaFallbackPolicy = iota
+
+	// FallbackToAnyReplica continues to send reads to ejected replicas
+	// when no read replica is healthy.
+	FallbackToAnyReplica
+
+	// FallbackNever returns ErrNoHealthyReplica when no read replica is
+	// healthy.
+	FallbackNever
+)
+
+const (
+	// ReplicaEjected is emitted when a read replica is removed from the
+	// rotation after consecutive failures.
+	ReplicaEjected ReplicaEventType = iota
+
+	// ReplicaRecovered is emitted when a previously ejected read replica
+	// passes a health check and is restored to the rotation.
+	ReplicaRecovered
+)
+
+// offsetHistorySize is the number of primary replication offset samples
+// used to estimate replica staleness.
+const offsetHistorySize = 64
+
+// ErrNoHealthyReplica is returned when no read replica is healthy and the
+// client is configured not to fall back to another server.
+var ErrNoHealthyReplica = errors.New("no healthy read replica available")
+
+func newReplicaClient(primary *client, config *clientConfig) *replicaClient {
+	replicas := make([]*replica, 0, len(config.readAddrs))
+	for _, addr := range config.readAddrs {
+		replicas = append(replicas, &replica{
+			addr:    addr,
+			client:  newClient(config.replicaFactory([]string{addr}), config, RoleReplica, addr),
+			healthy: true,
+			offset:  -1,
+		})
+	}
+
+	c := &replicaClient{
+		primary:   primary,
+		replicas:  replicas,
+		policy:    config.replicaFallbackPolicy,
+		threshold: config.replicaEjectThreshold,
+		interval:  config.replicaCheckInterval,
+		maxLag:    config.replicaMaxLag,
+		offsets:   newOffsetTracker(offsetHistorySize),
+		backoff:   config.backoff,
+		clock:     config.clock,
+		logger:    config.logger,
+		halt:      make(chan struct{}),
+	}
+
+	if config.replicaEventHandler != nil {
+		c.startEventHandler(config.events, config.replicaEventHandler)
+	}
+
+	if c.interval > 0 {
+		c.wg.Add(1)
+		go c.checkLoop()
+	}
+
+	return c
+}
+
+//
+// Client Implementation
+
+func (c *replicaClient) ReadReplica() Client {
+	return c
+}
+
+func (c *replicaClient) ReadReplicaWithin(maxStaleness time.Duration) Client {
+	return &boundedReplicaClient{
+		replicaClient: c,
+		filter: func(r *replica) bool {
+			return r.stalenessKnown && r.staleness <= maxStaleness
+		},
+	}
+}
+
+func (c *replicaClient) ReadReplicaAfter(token ConsistencyToken) Client {
+	// The acknowledgement count of the token is not trusted here: the
+	// replicas which acknowledged the write may not be the ones configured
+	// on this client, so the offset of each replica is always checked.
+
+	return &boundedReplicaClient{
+		replicaClient: c,
+		filter: func(r *replica) bool {
+			return r.offset >= token.Offset
+		},
+	}
+}
+
+func (c *replicaClient) Close() {
+	c.haltOnce.Do(func() { close(c.halt) })
+	c.wg.Wait()
+	c.stopEventHandler()
+
+	for _, r := range c.replicas {
+		r.client.Close()
+	}
+}
+
+func (c *replicaClient) CloseContext(ctx context.Context) error {
+	c.haltOnce.Do(func() { close(c.halt) })
+	c.wg.Wait()
+	c.stopEventHandler()
+
+	var err error
+	for _, r := range c.replicas {
+		if clientErr := r.client.CloseContext(ctx); err == nil {
+			err = clientErr
+		}
+	}
+
+	return err
+}
+
+func (c *replicaClient) Do(command string, args ...interface{}) (interface{}, error) {
+	return c.DoContext(context.Background(), command, args...)
+}
+
+func (c *replicaClient) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	return c.doContext(ctx, nil, command, args)
+}
+
+func (c *replicaClient) Pipeline() Pipeline {
+	return newPipeline(c)
+}
+
+func (c *boundedReplicaClient) Do(command string, args ...interface{}) (interface{}, error) {
+	return c.DoContext(context.Background(), command, args...)
+}
+
+func (c *boundedReplicaClient) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	return c.doContext(ctx, c.filter, command, args)
+}
+
+func (c *boundedReplicaClient) Pipeline() Pipeline {
+	return newPipeline(c)
+}
+
+//
+// Replica Client Helper Functions
+
+// Invoke a command on a healthy replica matching the given filter.
+func (c *replicaClient) doContext(ctx context.Context, filter replicaFilter, command string, args []interface{}) (interface{}, error) {
+	ctx, span := c.primary.tracing.startCommand(ctx, command, args)
+	ctx, record := c.primary.commandLog.start(ctx)
+	result, err := c.withReplica(ctx, filter, func(ctx context.Context, c *client) (interface{}, error) { return c.do(ctx, command, args) })
+	c.primary.commandLog.finish(record, command, args, err)
+	endSpan(span, err)
+	return result, err
+}
+
+// Invoke a series of commands wrapped in MULTI and EXEC commands on
+// a healthy replica.
+func (c *replicaClient) pipeline(ctx context.Context, commands []commandPair) (interface{}, error) {
+	return c.pipelineFiltered(ctx, nil, commands)
+}
+
+// Invoke a series of commands wrapped in MULTI and EXEC commands on
+// a healthy replica which satisfies the client's consistency requirement.
+func (c *boundedReplicaClient) pipeline(ctx context.Context, commands []commandPair) (interface{}, error) {
+	return c.pipelineFiltered(ctx, c.filter, commands)
+}
+
+// Invoke a series of commands wrapped in MULTI and EXEC commands on a
+// healthy replica matching the given filter.
+func (c *replicaClient) pipelineFiltered(ctx context.Context, filter replicaFilter, commands []commandPair) (interface{}, error) {
+	ctx, span := c.primary.tracing.startPipeline(ctx, commands)
+	ctx, record := c.primary.commandLog.start(ctx)
+	result, err := c.withReplica(ctx, filter, func(ctx context.Context, c *client) (interface{}, error) { return c.doPipeline(ctx, commands) })
+	c.primary.commandLog.finishPipeline(record, commands, err)
+	endSpan(span, err)
+	return result, err
+}
+
+// Invoke the given function with a healthy replica. Connection errors
+// count against the replica and the function is retried, possibly on
+// another replica. If there are no healthy replicas, the function is
+// invoked according to the configured fallback policy. If a filter is
+// supplied, only replicas matching the filter are chosen and the function
+// is invoked on the primary if no such replica exists.
+func (c *replicaClient) withReplica(ctx context.Context, filter replicaFilter, f replicaFunc) (interface{}, error) {
+	// Get a copy of the backoff
+	backoff := c.backoff.Clone()
+
+	for attempt := 1; ; attempt++ {
+		r := c.choose(filter)
+		if r == nil && filter != nil {
+			// The replication state of each replica is only refreshed on the
+			// health check interval. Refresh the state of one replica before
+			// giving up so that recent writes don't always go to the primary.
+
+			if candidate := c.choose(nil); candidate != nil {
+				c.refresh(candidate)
+				r = c.choose(filter)
+			}
+		}
+
+		if r == nil {
+			if filter != nil {
+				return c.primary.withRetry(ctx, func(ctx context.Context) (interface{}, error) { return f(ctx, c.primary) })
+			}
+
+			return c.fallback(ctx, f)
+		}
+
+		attemptCtx, span := r.client.tracing.startAttempt(ctx, r.addr, attempt)
+		result, err := f(attemptCtx, r.client)
+		endSpan(span, err)
+
+		if !isReplicaFailure(r, err) {
+			c.markSuccess(r)
+			return result, err
+		}
+
+		c.markFailure(r, err)
+
+		// Log error here so it's not silently dropped
+		c.logger.Warn("Received error from replica, retrying", "addr", r.addr, "attempt", attempt, "error", err)
+		c.primary.commandLog.retried(ctx)
+		r.client.events.emit(Event{Type: RetryAttempt, Attempt: attempt, Err: err})
+
+		// Backoff, don't thrash the pool
+		select {
+		case <-c.clock.After(backoff.NextInterval()):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Invoke the given function when no replica is healthy.
+func (c *replicaClient) fallback(ctx context.Context, f replicaFunc) (interface{}, error) {
+	switch c.policy {
+	case FallbackToPrimary:
+		return c.primary.withRetry(ctx, func(ctx context.Context) (interface{}, error) { return f(ctx, c.primary) })
+
+	case FallbackToAnyReplica:
+		r := c.replicas[atomic.AddUint64(&c.next, 1)%uint64(len(c.replicas))]
+		return r.client.withRetry(ctx, func(ctx context.Context) (interface{}, error) { return f(ctx, r.client) })
+	}
+
+	return nil, ErrNoHealthyReplica
+}
+
+// Choose the next eligible replica in round-robin order. Returns nil
+// if no replica is eligible.
+func (c *replicaClient) choose(filter replicaFilter) *replica {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	n := uint64(len(c.replicas))
+	offset := atomic.AddUint64(&c.next, 1)
+
+	for i := uint64(0); i < n; i++ {
+		if r := c.replicas[(offset+i)%n]; c.eligible(r, filter) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// Determine if the replica can serve a read. A replica is eligible if it
+// is healthy and does not lag behind the primary by more than the max lag
+// configured for the client. Once an offset of the primary has been
+// recorded, a replica whose staleness is unknown (e.g. because it is behind
+// every recorded offset) is not eligible when a max lag is configured. If a
+// filter is supplied, the replica must also match the filter. This method
+// assumes the caller holds the mutex.
+func (c *replicaClient) eligible(r *replica, filter replicaFilter) bool {
+	if !r.healthy {
+		return false
+	}
+
+	if c.maxLag > 0 {
+		if r.stalenessKnown && r.staleness > c.maxLag {
+			return false
+		}
+
+		if !r.stalenessKnown && !c.offsets.empty() {
+			return false
+		}
+	}
+
+	return filter == nil || filter(r)
+}
+
+// Periodically send a PING to each replica until the client is closed.
+func (c *replicaClient) checkLoop() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.halt:
+			return
+		case <-c.clock.After(c.interval):
+		}
+
+		c.check()
+	}
+}
+
+// Send a PING to each replica and update its health. The replication
+// offset of the primary and each healthy replica is also queried in order
+// to update the replication state of each replica.
+func (c *replicaClient) check() {
+	if info, err := c.replicationInfo(c.primary); err == nil {
+		c.offsets.record(c.clock.Now(), info.offset)
+		c.observeRole(c.primary.events, &c.primaryRole, info.role)
+	} else {
+		c.logger.Warn("Could not determine replication offset of primary", "error", err)
+	}
+
+	for _, r := range c.replicas {
+		if _, err := r.client.doTimeout(c.interval, "PING"); err != nil {
+			if err == ErrNoConnection && !r.client.dials.unreachable() {
+				// The pool is exhausted but the replica is reachable, so
+				// there is nothing to learn from this check
+				c.logger.Debug("Skipped health check of busy replica", "addr", r.addr)
+				continue
+			}
+
+			c.logger.Warn("Health check of replica failed", "addr", r.addr, "error", err)
+			c.markFailure(r, err)
+			continue
+		}
+
+		c.markSuccess(r)
+		c.refresh(r)
+	}
+}
+
+// Query the replication offset of the replica and update its offset and
+// estimated staleness.
+func (c *replicaClient) refresh(r *replica) {
+	info, err := c.replicationInfo(r.client)
+	if err != nil {
+		c.logger.Warn("Could not determine replication offset of replica", "addr", r.addr, "error", err)
+
+		c.mutex.Lock()
+		r.stalenessKnown = false
+		c.mutex.Unlock()
+		return
+	}
+
+	c.observeRole(r.client.events, &r.role, info.role)
+	staleness, ok := c.staleness(info)
+
+	c.mutex.Lock()
+	r.offset = info.offset
+	r.staleness = staleness
+	r.stalenessKnown = ok
+	c.mutex.Unlock()
+}
+
+// Record the role reported by a server. A change from the previously
+// reported role indicates a failover.
+func (c *replicaClient) observeRole(events eventEmitter, previous *string, role string) {
+	c.mutex.Lock()
+	from := *previous
+	*previous = role
+	c.mutex.Unlock()
+
+	if from != "" && from != role {
+		c.logger.Warn("Server role changed, a failover may have occurred", "addr", events.addr, "from", from, "to", role)
+		events.emit(Event{Type: FailoverDetected, ServerRole: role})
+	}
+}
+
+// Estimate how far a replica lags behind the primary. A replica which has
+// lost its link to the primary is considered infinitely stale. If the
+// replication offset of the primary or of the replica is unknown, the time
+// since the replica last interacted with the primary is used instead.
+func (c *replicaClient) staleness(info replicationInfo) (time.Duration, bool) {
+	if !info.linkUp {
+		return maxStaleness, true
+	}
+
+	if info.offset >= 0 && !c.offsets.empty() {
+		return c.offsets.staleness(info.offset)
+	}
+
+	if info.lastIOSeconds >= 0 {
+		return time.Duration(info.lastIOSeconds) * time.Second, true
+	}
+
+	return 0, false
+}
+
+// Query the replication section of the INFO command on the given client.
+func (c *replicaClient) replicationInfo(client *client) (replicationInfo, error) {
+	reply, err := client.doTimeout(c.interval, "INFO", "replication")
+	if err != nil {
+		return replicationInfo{}, err
+	}
+
+	return parseReplicationInfo(reply)
+}
+
+// Reset the failure count of the replica and restore it to the rotation
+// if it was previously ejected.
+func (c *replicaClient) markSuccess(r *replica) {
+	c.mutex.Lock()
+	recovered := !r.healthy
+	r.healthy = true
+	r.failures = 0
+	c.mutex.Unlock()
+
+	if recovered {
+		c.logger.Info("Replica has recovered", "addr", r.addr)
+		r.client.events.emit(Event{Type: ReplicaStateChanged, Healthy: true})
+	}
+}
+
+// Increase the failure count of the replica and eject it from the rotation
+// if it has failed too many consecutive times.
+func (c *replicaClient) markFailure(r *replica, err error) {
+	c.mutex.Lock()
+	r.failures++
+	ejected := r.healthy && r.failures >= c.threshold
+	if ejected {
+		r.healthy = false
+	}
+	c.mutex.Unlock()
+
+	if ejected {
+		c.logger.Error("Ejecting replica after consecutive failures", "addr", r.addr, "failures", c.threshold)
+		r.client.events.emit(Event{Type: ReplicaStateChanged, Err: err})
+	}
+}
+
+// Invoke the handler with each replica state change published to the event
+// bus. The handler runs on its own goroutine so that a slow handler does not
+// block the reads and health checks which eject and restore replicas.
+func (c *replicaClient) startEventHandler(bus *eventBus, handler ReplicaEventHandler) {
+	c.handlerSub = bus.subscribe(ReplicaStateChanged)
+	c.handlerDone = make(chan struct{})
+
+	go func() {
+		defer close(c.handlerDone)
+
+		for event := range c.handlerSub.C() {
+			replicaEvent := ReplicaEvent{Type: ReplicaEjected, Addr: event.Addr, Err: event.Err}
+			if event.Healthy {
+				replicaEvent.Type = ReplicaRecovered
+			}
+
+			handler(replicaEvent)
+		}
+	}()
+}
+
+// Stop forwarding events to the handler once it has received each event
+// published before the call.
+func (c *replicaClient) stopEventHandler() {
+	if c.handlerSub == nil {
+		return
+	}
+
+	c.handlerSub.Close()
+	<-c.handlerDone
+}
+
+// Determine if the error indicates that the replica is unreachable. Protocol
+// and redis logic errors do not count against the health of a replica, nor
+// does a borrow timeout while the replica can still be dialed (its pool is
+// busy, not broken).
+func isReplicaFailure(r *replica, err error) bool {
+	if err == ErrNoConnection {
+		return r.client.dials.unreachable()
+	}
+
+	_, ok := err.(connErr)
+	return ok
+}
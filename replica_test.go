@@ -0,0 +1,550 @@
+package deepjoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	. "github.com/efritz/go-mockgen/matchers"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type ReplicaSuite struct{}
+
+func (s *ReplicaSuite) TestRoundRobin(t sweet.T) {
+	var (
+		pool1 = mocks.NewMockPool()
+		pool2 = mocks.NewMockPool()
+		conn1 = mocks.NewMockConn()
+		conn2 = mocks.NewMockConn()
+		c     = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool1, nil), makeClient(pool2, nil))
+	)
+
+	pool1.BorrowFunc.SetDefaultReturn(conn1, true)
+	pool2.BorrowFunc.SetDefaultReturn(conn2, true)
+
+	for i := 0; i < 10; i++ {
+		c.Do("get", "foo")
+	}
+
+	Expect(conn1.DoFunc).To(BeCalledN(5))
+	Expect(conn2.DoFunc).To(BeCalledN(5))
+}
+
+func (s *ReplicaSuite) TestEjectAndFallbackToPrimary(t sweet.T) {
+	var (
+		pool1  = mocks.NewMockPool()
+		pool2  = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		clock  = glock.NewMockClock()
+		c      = makeReplicaClient(makeClient(pool1, clock), clock, makeUnreachableClient(pool2, clock))
+		events = watchReplicaEvents(c)
+	)
+
+	pool1.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn("primary", nil)
+
+	go func() {
+		// Unlock the after call in client
+		clock.BlockingAdvance(time.Second)
+	}()
+
+	Expect(c.Do("get", "foo")).To(Equal("primary"))
+
+	c.stopEventHandler()
+	Expect(drainReplicaEvents(events)).To(Equal([]ReplicaEvent{{Type: ReplicaEjected, Addr: "replica0", Err: ErrNoConnection}}))
+}
+
+func (s *ReplicaSuite) TestEjectionThreshold(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		c    = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeUnreachableClient(pool, nil))
+	)
+
+	c.threshold = 3
+
+	for i := 0; i < 2; i++ {
+		c.check()
+		Expect(c.choose(nil)).NotTo(BeNil())
+	}
+
+	c.check()
+	Expect(c.choose(nil)).To(BeNil())
+}
+
+func (s *ReplicaSuite) TestBusyReplicaNotEjected(t sweet.T) {
+	var (
+		pool  = mocks.NewMockPool()
+		clock = glock.NewMockClock()
+		c     = makeReplicaClient(makeClient(mocks.NewMockPool(), clock), clock, makeClient(pool, clock))
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Do("get", "foo")
+		Expect(err).To(Equal(ErrNoConnection))
+		c.check()
+	}
+
+	Expect(c.choose(nil)).NotTo(BeNil())
+	Expect(c.replicas[0].failures).To(Equal(0))
+}
+
+func (s *ReplicaSuite) TestFallbackNever(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		c    = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool, nil))
+	)
+
+	c.policy = FallbackNever
+	c.replicas[0].healthy = false
+
+	_, err := c.Do("get", "foo")
+	Expect(err).To(Equal(ErrNoHealthyReplica))
+	Expect(pool.BorrowFunc).NotTo(BeCalled())
+}
+
+func (s *ReplicaSuite) TestFallbackToAnyReplica(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool, nil))
+	)
+
+	c.policy = FallbackToAnyReplica
+	c.replicas[0].healthy = false
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn("replica", nil)
+
+	Expect(c.Do("get", "foo")).To(Equal("replica"))
+}
+
+func (s *ReplicaSuite) TestRedisErrorDoesNotEject(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool, nil))
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn(nil, errors.New("WRONGTYPE"))
+
+	_, err := c.Do("get", "foo")
+	Expect(err).To(MatchError("WRONGTYPE"))
+	Expect(c.choose(nil)).NotTo(BeNil())
+}
+
+func (s *ReplicaSuite) TestHealthCheckRecovers(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		c      = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeUnreachableClient(pool, nil))
+		events = watchReplicaEvents(c)
+	)
+
+	pool.BorrowTimeoutFunc.PushReturn(nil, false)
+	pool.BorrowTimeoutFunc.PushReturn(conn, true)
+
+	c.check()
+	Expect(c.choose(nil)).To(BeNil())
+
+	c.check()
+	Expect(c.choose(nil)).NotTo(BeNil())
+	Expect(conn.DoFunc).To(BeCalledWith("PING"))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+
+	c.stopEventHandler()
+	Expect(drainReplicaEvents(events)).To(Equal([]ReplicaEvent{
+		{Type: ReplicaEjected, Addr: "replica0", Err: ErrNoConnection},
+		{Type: ReplicaRecovered, Addr: "replica0"},
+	}))
+}
+
+func (s *ReplicaSuite) TestEventHandlerDoesNotBlockReads(t sweet.T) {
+	var (
+		pool    = mocks.NewMockPool()
+		c       = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeUnreachableClient(pool, nil))
+		bus     = attachEventBus(c)
+		handled = make(chan ReplicaEvent)
+	)
+
+	c.startEventHandler(bus, func(event ReplicaEvent) { handled <- event })
+	defer c.stopEventHandler()
+
+	// The handler blocks until the event is received below
+	c.check()
+	Expect(c.choose(nil)).To(BeNil())
+
+	var event ReplicaEvent
+	Eventually(handled).Should(Receive(&event))
+	Expect(event.Type).To(Equal(ReplicaEjected))
+}
+
+func (s *ReplicaSuite) TestCheckLoop(t sweet.T) {
+	var (
+		pool  = mocks.NewMockPool()
+		clock = glock.NewMockClock()
+		c     = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), clock, makeUnreachableClient(pool, nil))
+	)
+
+	c.wg.Add(1)
+	go c.checkLoop()
+
+	clock.BlockingAdvance(time.Second)
+	Eventually(func() *replica { return c.choose(nil) }).Should(BeNil())
+
+	c.Close()
+	Expect(pool.CloseFunc).To(BeCalled())
+}
+
+func (s *ReplicaSuite) TestCloseTwice(t sweet.T) {
+	var (
+		pool  = mocks.NewMockPool()
+		clock = glock.NewMockClock()
+		c     = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), clock, makeClient(pool, nil))
+	)
+
+	c.wg.Add(1)
+	go c.checkLoop()
+
+	Expect(c.CloseContext(context.Background())).To(BeNil())
+	Expect(c.Close).NotTo(Panic())
+	Expect(c.CloseContext(context.Background())).To(BeNil())
+}
+
+func (s *ReplicaSuite) TestCheckUpdatesStaleness(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		replicaPool = mocks.NewMockPool()
+		primaryConn = mocks.NewMockConn()
+		replicaConn = mocks.NewMockConn()
+		clock       = glock.NewMockClock()
+		c           = makeReplicaClient(makeClient(primaryPool, clock), clock, makeClient(replicaPool, clock))
+	)
+
+	primaryPool.BorrowTimeoutFunc.SetDefaultReturn(primaryConn, true)
+	replicaPool.BorrowTimeoutFunc.SetDefaultReturn(replicaConn, true)
+	primaryConn.DoFunc.PushReturn([]byte("role:master\r\nmaster_repl_offset:100\r\n"), nil)
+	primaryConn.DoFunc.PushReturn([]byte("role:master\r\nmaster_repl_offset:200\r\n"), nil)
+	replicaConn.DoFunc.SetDefaultHook(func(command string, args ...interface{}) (interface{}, error) {
+		if command == "PING" {
+			return "PONG", nil
+		}
+
+		return []byte("role:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:1\r\nslave_repl_offset:150\r\n"), nil
+	})
+
+	c.check()
+	Expect(c.replicas[0].stalenessKnown).To(BeTrue())
+	Expect(c.replicas[0].staleness).To(BeZero())
+
+	clock.Advance(time.Second * 10)
+	c.check()
+	Expect(c.replicas[0].stalenessKnown).To(BeTrue())
+	Expect(c.replicas[0].staleness).To(Equal(time.Second * 5))
+}
+
+func (s *ReplicaSuite) TestCheckBehindPrimarySample(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		replicaPool = mocks.NewMockPool()
+		primaryConn = mocks.NewMockConn()
+		replicaConn = mocks.NewMockConn()
+		clock       = glock.NewMockClock()
+		c           = makeReplicaClient(makeClient(primaryPool, clock), clock, makeClient(replicaPool, clock))
+	)
+
+	primaryPool.BorrowTimeoutFunc.SetDefaultReturn(primaryConn, true)
+	replicaPool.BorrowTimeoutFunc.SetDefaultReturn(replicaConn, true)
+	primaryConn.DoFunc.SetDefaultReturn([]byte("role:master\r\nmaster_repl_offset:200\r\n"), nil)
+	replicaConn.DoFunc.SetDefaultHook(func(command string, args ...interface{}) (interface{}, error) {
+		if command == "PING" {
+			return "PONG", nil
+		}
+
+		return []byte("role:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:0\r\nslave_repl_offset:150\r\n"), nil
+	})
+
+	// The last interaction with the primary does not make a lagging replica fresh
+	c.check()
+	Expect(c.replicas[0].stalenessKnown).To(BeFalse())
+}
+
+func (s *ReplicaSuite) TestCheckLinkDown(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool, nil))
+	)
+
+	pool.BorrowTimeoutFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.PushReturn("PONG", nil)
+	conn.DoFunc.PushReturn([]byte("role:slave\r\nmaster_link_status:down\r\nslave_repl_offset:150\r\n"), nil)
+
+	c.check()
+	Expect(c.replicas[0].stalenessKnown).To(BeTrue())
+	Expect(c.replicas[0].staleness).To(Equal(maxStaleness))
+}
+
+func (s *ReplicaSuite) TestMaxLag(t sweet.T) {
+	var (
+		pool1 = mocks.NewMockPool()
+		pool2 = mocks.NewMockPool()
+		conn  = mocks.NewMockConn()
+		c     = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool1, nil), makeClient(pool2, nil))
+	)
+
+	c.maxLag = time.Second
+	c.replicas[0].staleness = time.Second * 2
+	c.replicas[0].stalenessKnown = true
+	pool2.BorrowFunc.SetDefaultReturn(conn, true)
+
+	for i := 0; i < 10; i++ {
+		c.Do("get", "foo")
+	}
+
+	Expect(pool1.BorrowFunc).NotTo(BeCalled())
+	Expect(conn.DoFunc).To(BeCalledN(10))
+}
+
+func (s *ReplicaSuite) TestMaxLagBehindEverySample(t sweet.T) {
+	var (
+		pool1 = mocks.NewMockPool()
+		pool2 = mocks.NewMockPool()
+		conn  = mocks.NewMockConn()
+		c     = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool1, nil), makeClient(pool2, nil))
+	)
+
+	c.maxLag = time.Second
+	c.offsets.record(time.Now(), 100)
+	c.replicas[0].offset = 50
+	c.replicas[0].stalenessKnown = false
+	c.replicas[1].offset = 100
+	c.replicas[1].stalenessKnown = true
+	pool2.BorrowFunc.SetDefaultReturn(conn, true)
+
+	for i := 0; i < 10; i++ {
+		c.Do("get", "foo")
+	}
+
+	Expect(pool1.BorrowFunc).NotTo(BeCalled())
+	Expect(conn.DoFunc).To(BeCalledN(10))
+}
+
+func (s *ReplicaSuite) TestReadReplicaWithin(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		replicaPool = mocks.NewMockPool()
+		primaryConn = mocks.NewMockConn()
+		replicaConn = mocks.NewMockConn()
+		c           = makeReplicaClient(makeClient(primaryPool, nil), nil, makeClient(replicaPool, nil))
+	)
+
+	primaryPool.BorrowFunc.SetDefaultReturn(primaryConn, true)
+	replicaPool.BorrowFunc.SetDefaultReturn(replicaConn, true)
+	primaryConn.DoFunc.SetDefaultReturn("primary", nil)
+	replicaConn.DoFunc.SetDefaultReturn("replica", nil)
+
+	// Unknown staleness
+	Expect(c.ReadReplicaWithin(time.Second).Do("get", "foo")).To(Equal("primary"))
+
+	c.replicas[0].staleness = time.Millisecond * 500
+	c.replicas[0].stalenessKnown = true
+	Expect(c.ReadReplicaWithin(time.Second).Do("get", "foo")).To(Equal("replica"))
+	Expect(c.ReadReplicaWithin(time.Millisecond*100).Do("get", "foo")).To(Equal("primary"))
+
+	// Unbounded reads are unaffected
+	Expect(c.Do("get", "foo")).To(Equal("replica"))
+}
+
+func (s *ReplicaSuite) TestReadReplicaWithinConcurrentWithCheck(t sweet.T) {
+	var (
+		clock   = glock.NewRealClock()
+		primary = makeClient(NewPool(makeReplyDialer("role:master\r\nmaster_repl_offset:100\r\n"), 4, NilLogger, noopBreakerFunc, clock), clock)
+		replica = makeClient(NewPool(makeReplyDialer("role:slave\r\nmaster_link_status:down\r\n"), 4, NilLogger, noopBreakerFunc, clock), clock)
+		c       = makeReplicaClient(primary, clock, replica)
+		stop    = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	defer c.Close()
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				c.check()
+			}
+		}
+	}()
+
+	// The replica is never fresh enough, so each read refreshes it
+	for i := 0; i < 1000; i++ {
+		_, err := c.ReadReplicaWithin(time.Second).Do("get", "foo")
+		Expect(err).To(BeNil())
+	}
+
+	close(stop)
+	<-done
+}
+
+func (s *ReplicaSuite) TestReadReplicaAfter(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		replicaPool = mocks.NewMockPool()
+		primaryConn = mocks.NewMockConn()
+		replicaConn = mocks.NewMockConn()
+		c           = makeReplicaClient(makeClient(primaryPool, nil), nil, makeClient(replicaPool, nil))
+		token       = ConsistencyToken{Offset: 150}
+	)
+
+	primaryPool.BorrowFunc.SetDefaultReturn(primaryConn, true)
+	replicaPool.BorrowFunc.SetDefaultReturn(replicaConn, true)
+	replicaPool.BorrowTimeoutFunc.SetDefaultReturn(replicaConn, true)
+	primaryConn.DoFunc.SetDefaultReturn("primary", nil)
+	replicaConn.DoFunc.SetDefaultReturn("replica", nil)
+
+	c.replicas[0].offset = 100
+	replicaConn.DoFunc.PushReturn([]byte("role:slave\r\nmaster_link_status:up\r\nslave_repl_offset:120\r\n"), nil)
+	Expect(c.ReadReplicaAfter(token).Do("get", "foo")).To(Equal("primary"))
+	Expect(c.replicas[0].offset).To(Equal(int64(120)))
+
+	// Refreshes offset on demand
+	replicaConn.DoFunc.PushReturn([]byte("role:slave\r\nmaster_link_status:up\r\nslave_repl_offset:150\r\n"), nil)
+	Expect(c.ReadReplicaAfter(token).Do("get", "foo")).To(Equal("replica"))
+	Expect(c.replicas[0].offset).To(Equal(int64(150)))
+
+	// Does not refresh if replica has caught up
+	Expect(c.ReadReplicaAfter(token).Do("get", "foo")).To(Equal("replica"))
+	Expect(replicaPool.BorrowTimeoutFunc).To(BeCalledN(2))
+}
+
+func (s *ReplicaSuite) TestReadReplicaAfterAcknowledged(t sweet.T) {
+	var (
+		primaryPool = mocks.NewMockPool()
+		replicaPool = mocks.NewMockPool()
+		primaryConn = mocks.NewMockConn()
+		replicaConn = mocks.NewMockConn()
+		c           = makeReplicaClient(makeClient(primaryPool, nil), nil, makeClient(replicaPool, nil))
+	)
+
+	primaryPool.BorrowFunc.SetDefaultReturn(primaryConn, true)
+	replicaPool.BorrowFunc.SetDefaultReturn(replicaConn, true)
+	replicaPool.BorrowTimeoutFunc.SetDefaultReturn(replicaConn, true)
+	primaryConn.DoFunc.SetDefaultReturn("primary", nil)
+	replicaConn.DoFunc.SetDefaultReturn("replica", nil)
+
+	// The acknowledgement may have come from a replica not configured here
+	replicaConn.DoFunc.PushReturn([]byte("role:slave\r\nmaster_link_status:up\r\nslave_repl_offset:120\r\n"), nil)
+	Expect(c.ReadReplicaAfter(ConsistencyToken{Offset: 150, Replicas: 1}).Do("get", "foo")).To(Equal("primary"))
+	Expect(replicaPool.BorrowTimeoutFunc).To(BeCalledOnce())
+}
+
+func (s *ReplicaSuite) TestPipeline(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeReplicaClient(makeClient(mocks.NewMockPool(), nil), nil, makeClient(pool, nil))
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn([]int{1, 2}, nil)
+
+	pipeline := c.Pipeline()
+	pipeline.Add("get", "foo")
+	pipeline.Add("get", "bar")
+
+	result, err := pipeline.Run()
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal([]int{1, 2}))
+	Expect(conn.SendFunc).To(BeCalledWith("get", "foo"))
+	Expect(conn.SendFunc).To(BeCalledWith("get", "bar"))
+}
+
+//
+// Helpers
+
+// replyConn is a connection which responds to every command with the same
+// reply. Unlike the generated mocks, it is safe for concurrent use.
+type replyConn struct{ reply []byte }
+
+func (c *replyConn) Close() error                                                { return nil }
+func (c *replyConn) Do(command string, args ...interface{}) (interface{}, error) { return c.reply, nil }
+func (c *replyConn) Send(command string, args ...interface{}) error              { return nil }
+
+func makeReplyDialer(reply string) DialFunc {
+	return func() (Conn, error) { return &replyConn{[]byte(reply)}, nil }
+}
+
+// Create a client whose most recent dial failed, so that a borrow which
+// returns ErrNoConnection reads as an unreachable server.
+func makeUnreachableClient(pool Pool, clock glock.Clock) *client {
+	client := makeClient(pool, clock)
+	client.dials = &dialHealth{failing: 1}
+	return client
+}
+
+// Publish the state changes of each replica of the client to a new event
+// bus, which is returned.
+func attachEventBus(c *replicaClient) *eventBus {
+	bus := newEventBus(defaultEventBufferSize, glock.NewRealClock())
+	for _, r := range c.replicas {
+		r.client.events = eventEmitter{bus: bus, role: RoleReplica, addr: r.addr}
+	}
+
+	return bus
+}
+
+// Invoke an event handler for the replicas of the client which sends each
+// event to the returned channel.
+func watchReplicaEvents(c *replicaClient) <-chan ReplicaEvent {
+	events := make(chan ReplicaEvent, defaultEventBufferSize)
+	c.startEventHandler(attachEventBus(c), func(event ReplicaEvent) { events <- event })
+	return events
+}
+
+// Read the events already sent to the channel.
+func drainReplicaEvents(ch <-chan ReplicaEvent) []ReplicaEvent {
+	events := []ReplicaEvent{}
+	for {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func makeReplicaClient(primary *client, clock glock.Clock, clients ...*client) *replicaClient {
+	replicas := []*replica{}
+	for i, client := range clients {
+		replicas = append(replicas, &replica{
+			addr:    fmt.Sprintf("replica%d", i),
+			client:  client,
+			healthy: true,
+			offset:  -1,
+		})
+	}
+
+	return &replicaClient{
+		primary:   primary,
+		replicas:  replicas,
+		policy:    FallbackToPrimary,
+		threshold: 1,
+		interval:  time.Second,
+		offsets:   newOffsetTracker(offsetHistorySize),
+		backoff:   defaultBackoff,
+		clock:     clock,
+		logger:    NilLogger,
+		halt:      make(chan struct{}),
+	}
+}
@@ -0,0 +1,91 @@
+package deepjoy
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/efritz/glock"
+)
+
+type (
+	// sampledLogger limits the rate of debug messages, which are logged on
+	// every command. Within each interval, the first messages with a given
+	// text are logged and then only every nth message is logged. Messages
+	// at other levels are never sampled.
+	sampledLogger struct {
+		LeveledLogger
+		first      uint64
+		thereafter uint64
+		interval   int64
+		clock      glock.Clock
+		counters   [samplerBuckets]sampleCounter
+	}
+
+	sampleCounter struct {
+		resetAt int64
+		count   uint64
+	}
+)
+
+// samplerBuckets is the number of counters to which messages are hashed.
+// Distinct messages which hash to the same counter are sampled together.
+const samplerBuckets = 64
+
+const (
+	defaultLogSampleFirst      = 10
+	defaultLogSampleThereafter = 100
+)
+
+// Wrap the logger so that debug messages are sampled. If first is not
+// positive, the logger is returned unchanged.
+func newSampledLogger(logger LeveledLogger, first, thereafter int, interval time.Duration, clock glock.Clock) LeveledLogger {
+	if first <= 0 {
+		return logger
+	}
+
+	if thereafter < 1 {
+		thereafter = 1
+	}
+
+	return &sampledLogger{
+		LeveledLogger: logger,
+		first:         uint64(first),
+		thereafter:    uint64(thereafter),
+		interval:      int64(interval),
+		clock:         clock,
+	}
+}
+
+func (l *sampledLogger) Debug(msg string, fields ...interface{}) {
+	if l.sample(msg) {
+		l.LeveledLogger.Debug(msg, fields...)
+	}
+}
+
+// Determine if the message should be logged.
+func (l *sampledLogger) sample(msg string) bool {
+	var (
+		now     = l.clock.Now().UnixNano()
+		counter = &l.counters[hashMessage(msg)%samplerBuckets]
+		resetAt = atomic.LoadInt64(&counter.resetAt)
+	)
+
+	if now >= resetAt && atomic.CompareAndSwapInt64(&counter.resetAt, resetAt, now+l.interval) {
+		atomic.StoreUint64(&counter.count, 1)
+		return true
+	}
+
+	n := atomic.AddUint64(&counter.count, 1)
+	return n <= l.first || (n-l.first)%l.thereafter == 0
+}
+
+// Hash the message with FNV-1a.
+func hashMessage(msg string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(msg); i++ {
+		hash ^= uint32(msg[i])
+		hash *= 16777619
+	}
+
+	return hash
+}
@@ -0,0 +1,209 @@
+package deepjoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// sessionReset describes the commands required to restore the state of a
+// connection after a caller has run commands which alter its session. On
+// Redis 6.2+, the session is restored with RESET followed by the commands
+// which initialized the connection. Otherwise, only the database, watched
+// keys, and transactions can be restored, and a connection with any other
+// altered state is closed.
+type sessionReset struct {
+	database bool
+	unwatch  bool
+	discard  bool
+	reset    bool
+	close    bool
+}
+
+// ErrUnsafeCommand is returned when a command would leave a connection in
+// a state which cannot be restored before the connection is reused, such as
+// subscribing to a channel or disabling replies.
+var ErrUnsafeCommand = errors.New("command cannot be run on a pooled connection")
+
+// errDirtySession is the reason given for discarding a connection whose
+// session could not be restored.
+var errDirtySession = errors.New("connection session cannot be restored")
+
+// Return an error if the command cannot be run on a pooled connection.
+func checkCommand(command string, args []interface{}) error {
+	name := strings.ToUpper(command)
+
+	switch name {
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "MONITOR", "SYNC", "PSYNC":
+		return fmt.Errorf("%w (%s)", ErrUnsafeCommand, name)
+
+	case "CLIENT":
+		if subcommand(args) == "REPLY" {
+			return fmt.Errorf("%w (CLIENT REPLY)", ErrUnsafeCommand)
+		}
+	}
+
+	return nil
+}
+
+// Return an error if any command cannot be run on a pooled connection.
+func checkCommands(commands []commandPair) error {
+	for _, command := range commands {
+		if err := checkCommand(command.command, command.args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record the effect of a successful command on the session.
+func (r *sessionReset) add(command string, args []interface{}) {
+	switch strings.ToUpper(command) {
+	case "SELECT":
+		r.database = true
+
+	case "WATCH":
+		r.unwatch = true
+
+	case "MULTI":
+		r.discard = true
+
+	case "EXEC", "DISCARD", "UNWATCH":
+		r.discard = false
+		r.unwatch = false
+
+	case "AUTH", "HELLO", "READONLY", "READWRITE", "RESET":
+		r.reset = true
+
+	case "QUIT":
+		r.close = true
+
+	case "CLIENT":
+		switch subcommand(args) {
+		case "TRACKING", "SETNAME", "NO-EVICT", "NO-TOUCH":
+			r.reset = true
+		}
+	}
+}
+
+func (r sessionReset) dirty() bool {
+	return r.database || r.unwatch || r.discard || r.reset || r.close
+}
+
+// Return the upper-cased first argument of a command, if it is a string.
+func subcommand(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	switch v := args[0].(type) {
+	case string:
+		return strings.ToUpper(v)
+	case []byte:
+		return strings.ToUpper(string(v))
+	}
+
+	return ""
+}
+
+// Restore the state of a connection after the given commands succeeded.
+// If the session cannot be restored, an error is returned and the caller
+// must discard the connection rather than return it to the pool.
+func (c *client) resetSession(conn Conn, r sessionReset) error {
+	if !r.dirty() {
+		return nil
+	}
+
+	if r.close {
+		c.logger.Debug("Discarding connection after a command closed its session")
+		return errDirtySession
+	}
+
+	if c.restore != nil && atomic.LoadInt32(&c.resetUnsupported) == 0 {
+		supported, err := c.resetConn(conn)
+		if supported {
+			if err != nil {
+				c.logger.Warn("Could not reset connection session", "error", err)
+			}
+
+			return err
+		}
+	}
+
+	if r.reset {
+		c.logger.Debug("Discarding connection after a command altered its session")
+		return errDirtySession
+	}
+
+	var err error
+	if r.discard {
+		// Also unwatches all keys
+		_, err = conn.Do("DISCARD")
+	} else if r.unwatch {
+		_, err = conn.Do("UNWATCH")
+	}
+
+	if err == nil && r.database {
+		_, err = conn.Do("SELECT", c.database)
+	}
+
+	if err != nil {
+		c.logger.Warn("Could not reset connection session", "error", err)
+	}
+
+	return err
+}
+
+// Create a function which restores the initial state of a connection after
+// it has been sent RESET: the connection is authenticated with the given
+// credentials (or the configured password), the configured database is
+// selected, and the on-connect hooks are run again.
+func makeSessionRestorer(config *clientConfig, credentials CredentialsProvider) func(conn Conn) error {
+	if credentials == nil {
+		credentials = staticCredentialsProvider("", config.password)
+	}
+
+	return func(conn Conn) error {
+		if err := authenticate(conn, credentials, config); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.connectTimeout)
+		defer cancel()
+
+		for _, hook := range config.onConnect {
+			if err := hook(ctx, conn); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Send RESET and then restore the initial state of the connection, as the
+// RESET command also deauthenticates the connection, selects database zero,
+// and clears its name. The flag is false if the server does not support the
+// RESET command (Redis < 6.2), in which case the connection is unchanged and
+// RESET is not sent again by this client.
+func (c *client) resetConn(conn Conn) (bool, error) {
+	if _, err := conn.Do("RESET"); err != nil {
+		if isUnknownCommand(err) {
+			c.logger.Debug("Server does not support RESET, restoring sessions by command")
+			atomic.StoreInt32(&c.resetUnsupported, 1)
+			return false, nil
+		}
+
+		return true, err
+	}
+
+	return true, c.restore(conn)
+}
+
+// Determine if the error is the reply of a server to an unknown command.
+func isUnknownCommand(err error) bool {
+	return strings.HasPrefix(err.Error(), "ERR unknown command")
+}
@@ -0,0 +1,262 @@
+package deepjoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aphistic/sweet"
+	. "github.com/efritz/go-mockgen/matchers"
+	"github.com/gomodule/redigo/redis"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type SessionSuite struct{}
+
+func (s *SessionSuite) TestUnsafeCommands(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		c    = makeClient(pool, nil)
+	)
+
+	_, err := c.Do("subscribe", "foo")
+	Expect(errors.Is(err, ErrUnsafeCommand)).To(BeTrue())
+	Expect(err.Error()).To(ContainSubstring("SUBSCRIBE"))
+
+	_, err = c.Do("CLIENT", "reply", "off")
+	Expect(errors.Is(err, ErrUnsafeCommand)).To(BeTrue())
+
+	_, _, err = c.DoWithToken("MONITOR")
+	Expect(errors.Is(err, ErrUnsafeCommand)).To(BeTrue())
+
+	pipeline := c.Pipeline()
+	pipeline.Add("GET", "foo")
+	pipeline.Add("PSUBSCRIBE", "foo*")
+	_, err = pipeline.Run()
+	Expect(errors.Is(err, ErrUnsafeCommand)).To(BeTrue())
+
+	// Nothing is sent to the server
+	Expect(pool.BorrowFunc).NotTo(BeCalled())
+}
+
+func (s *SessionSuite) TestSelectRestoresDatabase(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	c.database = 3
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("select", 5)
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledN(2))
+	Expect(conn.DoFunc).To(BeCalledWith("SELECT", 3))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+}
+
+func (s *SessionSuite) TestWatchIsUnwatched(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("WATCH", "foo")
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledWith("UNWATCH"))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+}
+
+func (s *SessionSuite) TestMultiIsDiscarded(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("MULTI")
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledWith("DISCARD"))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+}
+
+func (s *SessionSuite) TestCleanCommandNotReset(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledOnce())
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+}
+
+func (s *SessionSuite) TestUnresettableSessionClosed(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("CLIENT", "TRACKING", "on")
+	Expect(err).To(BeNil())
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+	Expect(pool.ReleaseFunc).NotTo(BeCalled())
+}
+
+func (s *SessionSuite) TestResetFailureClosesConnection(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.PushReturn("OK", nil)
+	conn.DoFunc.PushReturn(nil, fmt.Errorf("utoh"))
+
+	_, err := c.Do("WATCH", "foo")
+	Expect(err).To(BeNil())
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+	Expect(pool.ReleaseFunc).NotTo(BeCalled())
+}
+
+func (s *SessionSuite) TestResetRestoresSession(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		c      = makeClient(pool, nil)
+		config = &clientConfig{password: "secret", database: 2, connectTimeout: time.Second}
+	)
+
+	WithOnConnect(func(ctx context.Context, conn Conn) error {
+		_, err := conn.Do("CLIENT", "SETNAME", "worker")
+		return err
+	})(config)
+
+	c.restore = makeSessionRestorer(config, nil)
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("CLIENT", "TRACKING", "on")
+	Expect(err).To(BeNil())
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+	Expect(pool.DiscardFunc).NotTo(BeCalled())
+
+	var commands [][]interface{}
+	for _, call := range conn.DoFunc.History() {
+		commands = append(commands, append([]interface{}{call.Arg0}, call.Arg1...))
+	}
+
+	Expect(commands).To(Equal([][]interface{}{
+		{"CLIENT", "TRACKING", "on"},
+		{"RESET"},
+		{"AUTH", "secret"},
+		{"SELECT", 2},
+		{"CLIENT", "SETNAME", "worker"},
+	}))
+}
+
+func (s *SessionSuite) TestResetRestoreFailureDiscardsConnection(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	c.restore = func(conn Conn) error { return fmt.Errorf("utoh") }
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("SELECT", 3)
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledWith("RESET"))
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+	Expect(pool.ReleaseFunc).NotTo(BeCalled())
+}
+
+func (s *SessionSuite) TestResetUnsupported(t sweet.T) {
+	var (
+		pool     = mocks.NewMockPool()
+		conn     = mocks.NewMockConn()
+		c        = makeClient(pool, nil)
+		restored = 0
+	)
+
+	c.restore = func(conn Conn) error {
+		restored++
+		return nil
+	}
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultHook(func(command string, args ...interface{}) (interface{}, error) {
+		if command == "RESET" {
+			return nil, redis.Error("ERR unknown command `RESET`, with args beginning with: ")
+		}
+
+		return "OK", nil
+	})
+
+	_, err := c.Do("WATCH", "foo")
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledWith("UNWATCH"))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+
+	// Unknown RESET command is remembered
+	_, err = c.Do("WATCH", "foo")
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledN(5))
+	Expect(restored).To(Equal(0))
+
+	// Altered state cannot be restored without RESET
+	_, err = c.Do("CLIENT", "SETNAME", "foo")
+	Expect(err).To(BeNil())
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+}
+
+func (s *SessionSuite) TestQuitDiscardsConnection(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	c.restore = func(conn Conn) error { return nil }
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	_, err := c.Do("QUIT")
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledOnce())
+	Expect(pool.DiscardFunc).To(BeCalledWith(conn))
+}
+
+func (s *SessionSuite) TestPipelineRestoresDatabase(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+
+	pipeline := c.Pipeline()
+	pipeline.Add("SELECT", 2)
+	pipeline.Add("GET", "foo")
+	_, err := pipeline.Run()
+	Expect(err).To(BeNil())
+	Expect(conn.DoFunc).To(BeCalledWith("EXEC"))
+	Expect(conn.DoFunc).To(BeCalledWith("SELECT", 0))
+	Expect(pool.ReleaseFunc).To(BeCalledWith(conn))
+}
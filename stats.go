@@ -0,0 +1,106 @@
+package deepjoy
+
+import "github.com/efritz/deepjoy/iface"
+
+type (
+	// ClientStats is a snapshot of the connection pools used by a client.
+	ClientStats = iface.ClientStats
+
+	// ClientState describes the servers used by a client.
+	ClientState = iface.ClientState
+
+	// ServerState describes a single server used by a client.
+	ServerState = iface.ServerState
+
+	// LeakReport describes a connection which has been borrowed from a
+	// pool for longer than the leak detection threshold.
+	LeakReport = iface.LeakReport
+)
+
+func (c *client) Stats() ClientStats {
+	stats := ClientStats{
+		Primary:  c.pool.Stats(),
+		Replicas: map[string]PoolStats{},
+	}
+
+	if replicaClient, ok := c.readReplicaClient.(*replicaClient); ok {
+		for _, r := range replicaClient.replicas {
+			stats.Replicas[r.addr] = r.client.pool.Stats()
+		}
+	}
+
+	stats.Total = stats.Primary
+	for _, replicaStats := range stats.Replicas {
+		stats.Total = addPoolStats(stats.Total, replicaStats)
+	}
+
+	return stats
+}
+
+func (c *replicaClient) Stats() ClientStats {
+	return c.primary.Stats()
+}
+
+func (c *client) State() ClientState {
+	state := ClientState{
+		Primary:  c.serverState(),
+		Replicas: []ServerState{},
+	}
+
+	if replicaClient, ok := c.readReplicaClient.(*replicaClient); ok {
+		for _, r := range replicaClient.replicas {
+			serverState := r.client.serverState()
+
+			replicaClient.mutex.RLock()
+			serverState.Healthy = r.healthy
+			serverState.Failures = r.failures
+			serverState.Staleness = r.staleness
+			serverState.StalenessKnown = r.stalenessKnown
+			replicaClient.mutex.RUnlock()
+
+			state.Replicas = append(state.Replicas, serverState)
+		}
+	}
+
+	return state
+}
+
+func (c *replicaClient) State() ClientState {
+	return c.primary.State()
+}
+
+// Describe the server of this client. The health of a read replica is
+// tracked by the replica client and is filled in by the caller.
+func (c *client) serverState() ServerState {
+	state := ServerState{
+		Role:    c.role,
+		Addr:    c.addr,
+		Healthy: true,
+		Stats:   c.pool.Stats(),
+		Leaks:   []LeakReport{},
+	}
+
+	if p, ok := c.pool.(*pool); ok && p.leaks != nil {
+		state.Leaks = p.leaks.leaks()
+	}
+
+	return state
+}
+
+func addPoolStats(a, b PoolStats) PoolStats {
+	return PoolStats{
+		Capacity:          a.Capacity + b.Capacity,
+		Open:              a.Open + b.Open,
+		Idle:              a.Idle + b.Idle,
+		InUse:             a.InUse + b.InUse,
+		Dials:             a.Dials + b.Dials,
+		DialFailures:      a.DialFailures + b.DialFailures,
+		BreakerRejections: a.BreakerRejections + b.BreakerRejections,
+		BreakerOpen:       a.BreakerOpen || b.BreakerOpen,
+		BorrowWaits:       a.BorrowWaits + b.BorrowWaits,
+		BorrowTimeouts:    a.BorrowTimeouts + b.BorrowTimeouts,
+		WaitDuration:      a.WaitDuration + b.WaitDuration,
+		ErrorClosed:       a.ErrorClosed + b.ErrorClosed,
+		Reaped:            a.Reaped + b.Reaped,
+	}
+}
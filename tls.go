@@ -0,0 +1,183 @@
+package deepjoy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	// tlsLoader creates the TLS configuration used to dial new connections.
+	// The CA bundle and the client certificate are read from disk and are
+	// reloaded whenever the files change, so that rotated certificates are
+	// used for new connections without recreating the client.
+	tlsLoader struct {
+		base       *tls.Config
+		caFile     string
+		certFile   string
+		keyFile    string
+		serverName string
+		skipVerify bool
+		logger     LeveledLogger
+		roots      *x509.CertPool
+		cert       *tls.Certificate
+		caStamp    fileStamp
+		certStamp  fileStamp
+		keyStamp   fileStamp
+		mutex      sync.Mutex
+	}
+
+	fileStamp struct {
+		modTime time.Time
+		size    int64
+	}
+)
+
+func newTLSLoader(config *clientConfig) *tlsLoader {
+	return &tlsLoader{
+		base:       config.tlsConfig,
+		caFile:     config.tlsCAFile,
+		certFile:   config.tlsCertFile,
+		keyFile:    config.tlsKeyFile,
+		serverName: config.tlsServerName,
+		skipVerify: config.tlsSkipVerify,
+		logger:     config.logger,
+	}
+}
+
+// Return a TLS configuration with the most recent CA bundle and client
+// certificate. If a file has changed but cannot be loaded (for example,
+// a certificate has been rotated but its key has not yet been written),
+// the previously loaded value is used.
+func (l *tlsLoader) config() (*tls.Config, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.reloadCA(); err != nil {
+		return nil, err
+	}
+
+	if err := l.reloadCert(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{}
+	if l.base != nil {
+		config = l.base.Clone()
+	}
+
+	if l.roots != nil {
+		config.RootCAs = l.roots
+	}
+
+	if cert := l.cert; cert != nil {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+
+	if l.serverName != "" {
+		config.ServerName = l.serverName
+	}
+
+	if l.skipVerify {
+		config.InsecureSkipVerify = true
+	}
+
+	return config, nil
+}
+
+func (l *tlsLoader) reloadCA() error {
+	if l.caFile == "" {
+		return nil
+	}
+
+	stamp, changed, err := l.changed(l.caFile, l.caStamp)
+	if err != nil || !changed {
+		return l.handleReloadError(err, l.roots != nil)
+	}
+
+	roots, err := loadCertPool(l.caFile)
+	if err != nil {
+		return l.handleReloadError(err, l.roots != nil)
+	}
+
+	l.roots = roots
+	l.caStamp = stamp
+	l.logger.Info("Loaded CA bundle", "path", l.caFile)
+	return nil
+}
+
+func (l *tlsLoader) reloadCert() error {
+	if l.certFile == "" && l.keyFile == "" {
+		return nil
+	}
+
+	certStamp, certChanged, err := l.changed(l.certFile, l.certStamp)
+	if err != nil {
+		return l.handleReloadError(err, l.cert != nil)
+	}
+
+	keyStamp, keyChanged, err := l.changed(l.keyFile, l.keyStamp)
+	if err != nil {
+		return l.handleReloadError(err, l.cert != nil)
+	}
+
+	if !certChanged && !keyChanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return l.handleReloadError(err, l.cert != nil)
+	}
+
+	l.cert = &cert
+	l.certStamp = certStamp
+	l.keyStamp = keyStamp
+	l.logger.Info("Loaded client certificate", "path", l.certFile)
+	return nil
+}
+
+// Determine if the file has changed since it was last loaded.
+func (l *tlsLoader) changed(path string, previous fileStamp) (fileStamp, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false, err
+	}
+
+	stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+	return stamp, stamp.size != previous.size || !stamp.modTime.Equal(previous.modTime), nil
+}
+
+// Log a reload error and suppress it if a previously loaded value exists.
+func (l *tlsLoader) handleReloadError(err error, loaded bool) error {
+	if err == nil {
+		return nil
+	}
+
+	if !loaded {
+		return err
+	}
+
+	l.logger.Warn("Could not reload TLS files, using previous values", "error", err)
+	return nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
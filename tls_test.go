@@ -0,0 +1,344 @@
+package deepjoy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aphistic/sweet"
+	. "github.com/onsi/gomega"
+)
+
+type TLSSuite struct{}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func (s *TLSSuite) TestMutualTLS(t sweet.T) {
+	var (
+		dir    = makeTempDir()
+		ca     = makeTestCA()
+		server = startTLSServer(ca, "127.0.0.1")
+	)
+
+	defer os.RemoveAll(dir)
+	defer server.Close()
+
+	writeFile(filepath.Join(dir, "ca.pem"), ca.pem)
+	writeClientCert(ca, dir, "client-1")
+
+	client := NewClient(
+		server.Addr(),
+		WithTLSCAFile(filepath.Join(dir, "ca.pem")),
+		WithTLSClientCertFiles(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")),
+	)
+
+	defer client.Close()
+	Expect(client.Do("PING")).To(Equal("client-1"))
+}
+
+func (s *TLSSuite) TestMissingClientCert(t sweet.T) {
+	var (
+		dir    = makeTempDir()
+		ca     = makeTestCA()
+		server = startTLSServer(ca, "127.0.0.1")
+	)
+
+	defer os.RemoveAll(dir)
+	defer server.Close()
+
+	writeFile(filepath.Join(dir, "ca.pem"), ca.pem)
+
+	dial := newConfig([]ConfigFunc{
+		WithTLSCAFile(filepath.Join(dir, "ca.pem")),
+	}).dialerFactory([]string{server.Addr()})
+
+	conn, err := dial()
+	if err == nil {
+		// TLS 1.3 reports client certificate errors on first read
+		_, err = conn.Do("PING")
+	}
+
+	Expect(err).NotTo(BeNil())
+}
+
+func (s *TLSSuite) TestCertificateReload(t sweet.T) {
+	var (
+		dir    = makeTempDir()
+		ca     = makeTestCA()
+		server = startTLSServer(ca, "127.0.0.1")
+	)
+
+	defer os.RemoveAll(dir)
+	defer server.Close()
+
+	writeFile(filepath.Join(dir, "ca.pem"), ca.pem)
+	writeClientCert(ca, dir, "client-1")
+
+	dial := newConfig([]ConfigFunc{
+		WithTLSCAFile(filepath.Join(dir, "ca.pem")),
+		WithTLSClientCertFiles(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")),
+	}).dialerFactory([]string{server.Addr()})
+
+	conn1, err := dial()
+	Expect(err).To(BeNil())
+	defer conn1.Close()
+	Expect(conn1.Do("PING")).To(Equal("client-1"))
+
+	writeClientCert(ca, dir, "client-2")
+
+	conn2, err := dial()
+	Expect(err).To(BeNil())
+	defer conn2.Close()
+	Expect(conn2.Do("PING")).To(Equal("client-2"))
+}
+
+func (s *TLSSuite) TestReloadKeepsPreviousCertOnError(t sweet.T) {
+	var (
+		dir = makeTempDir()
+		ca  = makeTestCA()
+	)
+
+	defer os.RemoveAll(dir)
+	writeClientCert(ca, dir, "client-1")
+
+	loader := newTLSLoader(newConfig([]ConfigFunc{
+		WithTLSClientCertFiles(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")),
+	}))
+
+	config, err := loader.config()
+	Expect(err).To(BeNil())
+	cert1, _ := config.GetClientCertificate(nil)
+
+	// Rotated certificate without matching key
+	certPEM, _ := makeCert(ca, "client-2", false)
+	writeFile(filepath.Join(dir, "client.pem"), certPEM)
+
+	config, err = loader.config()
+	Expect(err).To(BeNil())
+	cert2, _ := config.GetClientCertificate(nil)
+	Expect(cert2).To(BeIdenticalTo(cert1))
+}
+
+func (s *TLSSuite) TestMissingFiles(t sweet.T) {
+	loader := newTLSLoader(newConfig([]ConfigFunc{
+		WithTLSCAFile("/does/not/exist.pem"),
+	}))
+
+	_, err := loader.config()
+	Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func (s *TLSSuite) TestServerName(t sweet.T) {
+	var (
+		dir    = makeTempDir()
+		ca     = makeTestCA()
+		server = startTLSServer(ca, "redis.internal")
+	)
+
+	defer os.RemoveAll(dir)
+	defer server.Close()
+
+	writeFile(filepath.Join(dir, "ca.pem"), ca.pem)
+	writeClientCert(ca, dir, "client-1")
+
+	configs := []ConfigFunc{
+		WithTLSCAFile(filepath.Join(dir, "ca.pem")),
+		WithTLSClientCertFiles(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")),
+	}
+
+	_, err := newConfig(configs).dialerFactory([]string{server.Addr()})()
+	Expect(err).NotTo(BeNil())
+
+	conn, err := newConfig(append(configs, WithTLSServerName("redis.internal"))).dialerFactory([]string{server.Addr()})()
+	Expect(err).To(BeNil())
+	defer conn.Close()
+	Expect(conn.Do("PING")).To(Equal("client-1"))
+}
+
+func (s *TLSSuite) TestInsecureSkipVerify(t sweet.T) {
+	var (
+		dir    = makeTempDir()
+		ca     = makeTestCA()
+		server = startTLSServer(ca, "redis.internal")
+	)
+
+	defer os.RemoveAll(dir)
+	defer server.Close()
+
+	writeClientCert(ca, dir, "client-1")
+
+	conn, err := newConfig([]ConfigFunc{
+		WithTLSInsecureSkipVerify(),
+		WithTLSClientCertFiles(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")),
+	}).dialerFactory([]string{server.Addr()})()
+
+	Expect(err).To(BeNil())
+	defer conn.Close()
+	Expect(conn.Do("PING")).To(Equal("client-1"))
+}
+
+func (s *TLSSuite) TestHandshakeTimeout(t sweet.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	defer listener.Close()
+
+	go func() {
+		// Accept connections but never complete a handshake
+		conns := []net.Conn{}
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conns = append(conns, conn)
+		}
+	}()
+
+	dial := newConfig([]ConfigFunc{
+		WithTLSInsecureSkipVerify(),
+		WithConnectTimeout(time.Millisecond * 100),
+	}).dialerFactory([]string{listener.Addr().String()})
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := dial()
+		errs <- err
+	}()
+
+	Eventually(errs, time.Second*2).Should(Receive(HaveOccurred()))
+}
+
+//
+// Helpers
+
+// Start a RESP server which requires a client certificate signed by the
+// given CA and replies to every command with the client's common name.
+func startTLSServer(ca *testCA, host string) *respServer {
+	certPEM, keyPEM := makeCert(ca, host, true)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	Expect(err).To(BeNil())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    roots,
+	})
+
+	Expect(err).To(BeNil())
+
+	return newRESPServer(listener, func(conn net.Conn, args []string) string {
+		state := conn.(*tls.Conn).ConnectionState()
+		return respSimple(state.PeerCertificates[0].Subject.CommonName)
+	})
+}
+
+func makeTestCA() *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "deepjoy test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).To(BeNil())
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func makeCert(ca *testCA, name string, server bool) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).To(BeNil())
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = []net.IP{ip}
+		} else {
+			template.DNSNames = []string{name}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).To(BeNil())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).To(BeNil())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeClientCert(ca *testCA, dir, name string) {
+	certPEM, keyPEM := makeCert(ca, name, false)
+	writeFile(filepath.Join(dir, "client.pem"), certPEM)
+	writeFile(filepath.Join(dir, "client-key.pem"), keyPEM)
+}
+
+// Write the file and bump its modification time so that changes made in
+// quick succession are detected.
+func writeFile(path string, content []byte) {
+	mtime := time.Now()
+	if info, err := os.Stat(path); err == nil {
+		mtime = info.ModTime().Add(time.Second)
+	}
+
+	Expect(ioutil.WriteFile(path, content, 0600)).To(BeNil())
+	Expect(os.Chtimes(path, mtime, mtime)).To(BeNil())
+}
+
+func makeTempDir() string {
+	dir, err := ioutil.TempDir("", "deepjoy")
+	Expect(err).To(BeNil())
+	return dir
+}
@@ -0,0 +1,159 @@
+package deepjoy
+
+import (
+	"context"
+	"strings"
+)
+
+type (
+	// Tracer creates spans around the operations performed by a client. This
+	// interface is implemented by adapters for a particular tracing library
+	// (see the otel subpackage). Spans should be created as children of the
+	// span in the given context, if any, so that trace context propagated to
+	// the application (e.g. by a W3C traceparent header) is carried into the
+	// spans of the client.
+	Tracer interface {
+		// StartSpan starts a span with the given name and returns it along
+		// with a derived context containing the span.
+		StartSpan(ctx context.Context, name string) (context.Context, Span)
+	}
+
+	// Span is a single traced operation.
+	Span interface {
+		// SetAttribute attaches a key/value pair to the span. Values are
+		// strings, ints, or bools.
+		SetAttribute(key string, value interface{})
+
+		// RecordError marks the span as failed with the given error.
+		RecordError(err error)
+
+		// End completes the span.
+		End()
+	}
+
+	tracing struct {
+		tracer   Tracer
+		redactor redactor
+		database int
+		args     bool
+	}
+)
+
+const (
+	// AttrDBSystem identifies the database system (always redis).
+	AttrDBSystem = "db.system"
+
+	// AttrDatabaseIndex is the index of the database selected by the client.
+	AttrDatabaseIndex = "db.redis.database_index"
+
+	// AttrCommand is the upper-case name of the command, or PIPELINE.
+	AttrCommand = "db.operation"
+
+	// AttrStatement is the command and its redacted arguments (see
+	// WithArgRedaction). This is only attached when enabled with
+	// WithTracedArgs.
+	AttrStatement = "db.statement"
+
+	// AttrPipelineLength is the number of commands in a pipeline.
+	AttrPipelineLength = "db.redis.pipeline_length"
+
+	// AttrAddress is the address of the server to which an attempt was sent.
+	AttrAddress = "server.address"
+
+	// AttrAttempt is the one-based index of an attempt of an operation.
+	AttrAttempt = "db.redis.attempt"
+
+	// AttrErrorClass is the class of a failure, which is one of the Outcome
+	// constants.
+	AttrErrorClass = "error.type"
+)
+
+const (
+	// SpanNameAttempt is the name of the span of a single attempt of a
+	// command or pipeline, including the network round-trip.
+	SpanNameAttempt = "redis.attempt"
+
+	// SpanNameBorrow is the name of the span of a connection borrow.
+	SpanNameBorrow = "redis.borrow"
+)
+
+// Start the span of a command which covers each attempt.
+func (t tracing) startCommand(ctx context.Context, command string, args []interface{}) (context.Context, Span) {
+	if t.tracer == nil {
+		return ctx, nil
+	}
+
+	name := strings.ToUpper(command)
+	ctx, span := t.start(ctx, name)
+	span.SetAttribute(AttrCommand, name)
+
+	if t.args {
+		span.SetAttribute(AttrStatement, t.redactor.statement(name, args))
+	}
+
+	return ctx, span
+}
+
+// Start the span of a pipeline which covers each attempt.
+func (t tracing) startPipeline(ctx context.Context, commands []commandPair) (context.Context, Span) {
+	if t.tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := t.start(ctx, pipelineCommandLabel)
+	span.SetAttribute(AttrCommand, pipelineCommandLabel)
+	span.SetAttribute(AttrPipelineLength, len(commands))
+
+	if t.args {
+		statements := make([]string, 0, len(commands))
+		for _, command := range commands {
+			statements = append(statements, t.redactor.statement(strings.ToUpper(command.command), command.args))
+		}
+
+		span.SetAttribute(AttrStatement, strings.Join(statements, "\n"))
+	}
+
+	return ctx, span
+}
+
+// Start the span of a single attempt sent to the given address.
+func (t tracing) startAttempt(ctx context.Context, addr string, attempt int) (context.Context, Span) {
+	if t.tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := t.tracer.StartSpan(ctx, SpanNameAttempt)
+	span.SetAttribute(AttrAddress, addr)
+	span.SetAttribute(AttrAttempt, attempt)
+	return ctx, span
+}
+
+// Start the span of a connection borrow.
+func (t tracing) startBorrow(ctx context.Context) (context.Context, Span) {
+	if t.tracer == nil {
+		return ctx, nil
+	}
+
+	return t.tracer.StartSpan(ctx, SpanNameBorrow)
+}
+
+func (t tracing) start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := t.tracer.StartSpan(ctx, name)
+	span.SetAttribute(AttrDBSystem, "redis")
+	span.SetAttribute(AttrDatabaseIndex, t.database)
+	return ctx, span
+}
+
+// End the span (if tracing is enabled), recording the error if non-nil.
+func endSpan(span Span, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.SetAttribute(AttrErrorClass, outcome(err))
+		span.RecordError(err)
+	}
+
+	span.End()
+}
@@ -0,0 +1,166 @@
+package deepjoy
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aphistic/sweet"
+	"github.com/efritz/glock"
+	. "github.com/efritz/go-mockgen/matchers"
+	. "github.com/onsi/gomega"
+
+	"github.com/efritz/deepjoy/mocks"
+)
+
+type TracingSuite struct{}
+
+func makeTracedClient(pool Pool, clock glock.Clock, tracer Tracer) *client {
+	c := makeClient(pool, clock)
+	c.addr = "localhost:6379"
+	c.tracing = tracing{tracer: tracer, redactor: newRedactor(nil), database: 3}
+	return c
+}
+
+func (s *TracingSuite) TestDoSpans(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		tracer = NewRecordingTracer()
+		c      = makeTracedClient(pool, nil, tracer)
+	)
+
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn("bar", nil)
+
+	ctx, parent := tracer.StartSpan(context.Background(), "request")
+	result, err := c.DoContext(ctx, "get", "foo")
+	parent.End()
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal("bar"))
+
+	spans := tracer.Spans()
+	Expect(spans).To(HaveLen(4))
+	Expect(spans[1].Name).To(Equal("GET"))
+	Expect(spans[2].Name).To(Equal(SpanNameAttempt))
+	Expect(spans[3].Name).To(Equal(SpanNameBorrow))
+
+	for i := 1; i < len(spans); i++ {
+		Expect(spans[i].TraceID).To(Equal(spans[0].TraceID))
+		Expect(spans[i].ParentID).To(Equal(spans[i-1].SpanID))
+		Expect(spans[i].Ended()).To(BeTrue())
+		Expect(spans[i].Err).To(BeNil())
+	}
+
+	Expect(spans[1].Attributes).To(Equal(map[string]interface{}{
+		AttrDBSystem:      "redis",
+		AttrDatabaseIndex: 3,
+		AttrCommand:       "GET",
+	}))
+
+	Expect(spans[2].Attributes).To(Equal(map[string]interface{}{
+		AttrAddress: "localhost:6379",
+		AttrAttempt: 1,
+	}))
+}
+
+func (s *TracingSuite) TestDoSpansRetry(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn1  = mocks.NewMockConn()
+		conn2  = mocks.NewMockConn()
+		clock  = glock.NewMockClock()
+		tracer = NewRecordingTracer()
+		c      = makeTracedClient(pool, clock, tracer)
+	)
+
+	pool.BorrowFunc.PushReturn(conn1, true)
+	pool.BorrowFunc.PushReturn(conn2, true)
+	conn1.DoFunc.SetDefaultReturn(nil, connErr{io.EOF})
+	conn2.DoFunc.SetDefaultReturn("bar", nil)
+
+	go func() {
+		// Unlock the after call in client
+		clock.BlockingAdvance(time.Second)
+	}()
+
+	_, err := c.Do("GET", "foo")
+	Expect(err).To(BeNil())
+
+	attempts := []RecordedSpan{}
+	for _, span := range tracer.Spans() {
+		if span.Name == SpanNameAttempt {
+			attempts = append(attempts, span)
+		}
+	}
+
+	Expect(attempts).To(HaveLen(2))
+	Expect(attempts[0].Attributes[AttrAttempt]).To(Equal(1))
+	Expect(attempts[0].Attributes[AttrErrorClass]).To(Equal(OutcomeError))
+	Expect(attempts[0].Err).To(Equal(connErr{io.EOF}))
+	Expect(attempts[1].Attributes[AttrAttempt]).To(Equal(2))
+	Expect(attempts[1].Err).To(BeNil())
+}
+
+func (s *TracingSuite) TestPipelineSpan(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		conn   = mocks.NewMockConn()
+		tracer = NewRecordingTracer()
+		c      = makeTracedClient(pool, nil, tracer)
+	)
+
+	c.tracing.args = true
+	pool.BorrowFunc.SetDefaultReturn(conn, true)
+	conn.DoFunc.SetDefaultReturn([]interface{}{"OK", "bar"}, nil)
+
+	pipeline := c.Pipeline()
+	pipeline.Add("SET", "foo", "bar")
+	pipeline.Add("GET", "foo")
+	_, err := pipeline.RunContext(context.Background())
+	Expect(err).To(BeNil())
+
+	spans := tracer.Spans()
+	Expect(spans).To(HaveLen(3))
+	Expect(spans[0].Name).To(Equal("PIPELINE"))
+	Expect(spans[0].Attributes[AttrPipelineLength]).To(Equal(2))
+	Expect(spans[0].Attributes[AttrStatement]).To(Equal("SET foo ?\nGET foo"))
+}
+
+func (s *TracingSuite) TestDoContextCanceled(t sweet.T) {
+	var (
+		pool   = mocks.NewMockPool()
+		tracer = NewRecordingTracer()
+		c      = makeTracedClient(pool, nil, tracer)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.DoContext(ctx, "GET", "foo")
+	Expect(err).To(Equal(context.Canceled))
+	Expect(pool.BorrowFunc).NotTo(BeCalled())
+
+	spans := tracer.Spans()
+	Expect(spans).To(HaveLen(3))
+	Expect(spans[0].Attributes[AttrErrorClass]).To(Equal(OutcomeCanceled))
+}
+
+func (s *TracingSuite) TestDoContextDeadline(t sweet.T) {
+	var (
+		pool = mocks.NewMockPool()
+		conn = mocks.NewMockConn()
+		c    = makeClient(pool, nil)
+	)
+
+	pool.BorrowTimeoutFunc.SetDefaultReturn(conn, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := c.DoContext(ctx, "GET", "foo")
+	Expect(err).To(BeNil())
+	Expect(pool.BorrowFunc).NotTo(BeCalled())
+	Expect(pool.BorrowTimeoutFunc).To(BeCalled())
+	Expect(pool.BorrowTimeoutFunc.History()[0].Arg0).To(BeNumerically("~", time.Minute, time.Second))
+}